@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"os"
+
 	"github.com/go-pg/pg/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/sirupsen/logrus"
 	"github.com/warrenb95/railway-signals/internal/adapters/http"
+	"github.com/warrenb95/railway-signals/internal/adapters/memory"
 	"github.com/warrenb95/railway-signals/internal/adapters/repository"
 	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
 // TODO: move this to repository layer
@@ -22,24 +28,58 @@ func connectDB() *pg.DB {
 	return pg.Connect(opts)
 }
 
+// store is implemented by every repository adapter.
+type store interface {
+	domain.SignalStore
+	domain.TrackStore
+	domain.MileageStore
+	domain.ELRStore
+	domain.RouteStore
+	domain.PointStore
+	domain.Transactor
+}
+
 func main() {
-	db := connectDB()
-	defer db.Close()
+	storeType := flag.String("store", "postgres", "store backing the API, either postgres or memory (for tests and development only)")
+	flag.Parse()
+
 	logger := logrus.New()
 
-	repo, err := repository.NewPostgresRepository(db, logger)
-	if err != nil {
-		logger.WithError(err).Fatal("Creating new repository")
+	var repo store
+	switch *storeType {
+	case "postgres":
+		db := connectDB()
+		defer db.Close()
+
+		pgRepo, err := repository.NewPostgresRepository(db, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Creating new repository")
+		}
+		repo = pgRepo
+	case "memory":
+		repo = memory.NewRepository()
+	default:
+		logger.WithField("store", *storeType).Fatal("Unknown store type")
 	}
+	logger.WithField("store", *storeType).Info("Using store")
 
 	s := &application.Service{
 		Logger:       logger,
 		SignalStore:  repo,
 		TrackStore:   repo,
 		MileageStore: repo,
+		ELRStore:     repo,
+		RouteStore:   repo,
+		PointStore:   repo,
+		Transactor:   repo,
 	}
 
+	jobs := application.NewLoadJobs(s, os.TempDir())
+	go jobs.Run(context.Background())
+
 	e := echo.New()
+	e.HTTPErrorHandler = http.ErrorHandler(logger)
+	e.JSONSerializer = http.JSONSerializer{}
 
 	// middleware
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
@@ -55,24 +95,64 @@ func main() {
 		},
 	}))
 	e.Use(middleware.Recover())
+	e.Use(http.Units())
 
 	// Define API routes
 	// TODO: api groups?
 	e.GET("/api/v1/signals", http.ListSignalHandler(s))
+	e.GET("/api/v1/signals/nearest", http.NearestSignalsHandler(s))
 	e.GET("/api/v1/signals/:id", http.GetSignalHandler(s))
 	e.POST("/api/v1/signals", http.CreateSignalHandler(s))
 	e.PUT(("/api/v1/signals/:id"), http.UpdateSignalHandler(s))
 	e.DELETE(("/api/v1/signals/:id"), http.DeleteSignalHandler(s))
 
 	e.GET("/api/v1/tracks", http.ListTrackHandler(s))
+	e.GET("/api/v1/tracks/export", http.ExportTracksHandler(s))
 	e.GET("/api/v1/tracks/:id", http.GetTrackHandler(s))
 	e.POST("/api/v1/tracks", http.CreateTrackHandler(s))
 	e.PUT("/api/v1/tracks/:id", http.UpdateTrackHandler(s))
 	e.DELETE("/api/v1/tracks/:id", http.DeleteTrackHandler(s))
 
 	e.GET("/api/v1/signals/:id/tracks", http.GetSignalTracks(s))
+	e.GET("/api/v1/signals/:id/mileages", http.ListSignalMileagesHandler(s))
+
+	e.GET("/api/v1/tracks/:id/signals", http.ListTrackMileagesHandler(s))
+	e.GET("/api/v1/tracks/:id/signals/:signal_id", http.GetMileageHandler(s))
+	e.PUT("/api/v1/tracks/:id/signals/:signal_id", http.SetMileageHandler(s))
+	e.DELETE("/api/v1/tracks/:id/signals/:signal_id", http.DeleteMileageHandler(s))
+	e.GET("/api/v1/tracks/:id/signals/:signal_id/next", http.NextSignalHandler(s))
+	e.GET("/api/v1/tracks/:id/signals/:signal_id/previous", http.PreviousSignalHandler(s))
+	e.GET("/api/v1/tracks/:id/blocks", http.TrackBlocksHandler(s))
+	e.GET("/api/v1/blocks", http.ListBlocksHandler(s))
+	e.POST("/api/v1/tracks/:id/simulate", http.SimulateAspectsHandler(s))
+	e.POST("/api/v1/trains/simulate", http.SimulateMovementsHandler(s))
+
+	e.POST("/api/v1/tracks/load", http.LoadJSON(s, jobs))
+	e.POST("/api/v1/tracks/load/diff", http.DiffJSON(s))
+	e.GET("/api/v1/loads/:id", http.GetLoadJobHandler(jobs))
+	e.DELETE("/api/v1/loads/:id", http.CancelLoadJobHandler(jobs))
+
+	e.GET("/api/v1/elrs", http.ListELRsHandler(s))
+	e.GET("/api/v1/elrs/:code", http.GetELRHandler(s))
+	e.POST("/api/v1/elrs", http.CreateELRHandler(s))
+	e.PUT("/api/v1/elrs/:code", http.UpdateELRHandler(s))
+	e.DELETE("/api/v1/elrs/:code", http.DeleteELRHandler(s))
+	e.GET("/api/v1/elrs/:code/signals", http.ListELRSignalsHandler(s))
+
+	e.GET("/api/v1/routes", http.FindRouteHandler(s))
+
+	e.GET("/api/v1/points", http.ListPointsHandler(s))
+	e.GET("/api/v1/points/:id", http.GetPointHandler(s))
+	e.POST("/api/v1/points", http.CreatePointHandler(s))
+	e.PUT("/api/v1/points/:id", http.UpdatePointHandler(s))
+	e.DELETE("/api/v1/points/:id", http.DeletePointHandler(s))
 
-	e.POST("/api/v1/tracks/load", http.LoadJSON(s))
+	e.GET("/api/v1/interlocking/routes", http.ListInterlockingRoutesHandler(s))
+	e.GET("/api/v1/interlocking/routes/:id", http.GetInterlockingRouteHandler(s))
+	e.POST("/api/v1/interlocking/routes", http.CreateInterlockingRouteHandler(s))
+	e.DELETE("/api/v1/interlocking/routes/:id", http.DeleteInterlockingRouteHandler(s))
+	e.POST("/api/v1/interlocking/routes/:id/set", http.SetInterlockingRouteHandler(s))
+	e.POST("/api/v1/interlocking/routes/:id/release", http.ReleaseInterlockingRouteHandler(s))
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// TrackBlocksHandler lists the block sections of a track in the direction of travel, down by default.
+func TrackBlocksHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+		}
+
+		direction := domain.Direction(c.QueryParam("direction"))
+		switch direction {
+		case "":
+			direction = domain.DirectionDown
+		case domain.DirectionDown, domain.DirectionUp:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid direction, must be up or down")
+		}
+
+		blocks, err := s.TrackBlockSections(c.Request().Context(), trackID, direction)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{"blocks": blocks})
+	}
+}
+
+// ListBlocksHandler lists the block sections of the whole network, optionally only those on an ELR.
+func ListBlocksHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		blocks, nextPage, err := s.ListBlockSections(c.Request().Context(), c.QueryParam("elr"), limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"blocks":    blocks,
+			"next_page": nextPage,
+		})
+	}
+}
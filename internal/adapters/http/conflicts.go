@@ -0,0 +1,38 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// conflictPolicies reads the conflict policies from the query string.
+// on_conflict sets every policy, track_conflict, signal_conflict and mileage_conflict override it per entity type.
+func conflictPolicies(c echo.Context, defaults domain.ConflictPolicies) (domain.ConflictPolicies, error) {
+	policies := defaults
+	params := []struct {
+		name     string
+		policies []*domain.ConflictPolicy
+	}{
+		{"on_conflict", []*domain.ConflictPolicy{&policies.Tracks, &policies.Signals, &policies.Mileages}},
+		{"track_conflict", []*domain.ConflictPolicy{&policies.Tracks}},
+		{"signal_conflict", []*domain.ConflictPolicy{&policies.Signals}},
+		{"mileage_conflict", []*domain.ConflictPolicy{&policies.Mileages}},
+	}
+
+	for _, param := range params {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			continue
+		}
+
+		policy, err := domain.ParseConflictPolicy(value)
+		if err != nil {
+			return domain.ConflictPolicies{}, err
+		}
+		for _, p := range param.policies {
+			*p = policy
+		}
+	}
+
+	return policies, nil
+}
@@ -1,32 +1,81 @@
 package http
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/warrenb95/railway-signals/internal/application"
-	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
-// LoadJSON will load in a list of tracks and their associated signals.
-func LoadJSON(s *application.Service) echo.HandlerFunc {
+// LoadJSON queues a list of tracks and their associated signals to be loaded in the background.
+// With dry_run=true the list is only validated and the report is returned straight away.
+// The conflict policies default to application.DefaultLoadPolicies.
+func LoadJSON(s *application.Service, jobs *application.LoadJobs) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		body := c.Request().Body
-		bodyBytes, err := application.CleanJSON(body)
+		policies, err := conflictPolicies(c, application.DefaultLoadPolicies)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clean JSON input: " + err.Error()})
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 
-		var input domain.TrackSignalSlice
-		if err := json.Unmarshal(bodyBytes, &input); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload: " + err.Error()})
+		if dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run")); dryRun {
+			dec := application.NewTrackSignalsDecoder(c.Request().Body)
+			dec.Unit = requestUnit(c)
+			report, err := s.ValidateTrackSignals(c.Request().Context(), dec)
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(http.StatusOK, report)
+		}
+
+		job, err := jobs.Submit(c.Request().Context(), c.Request().Body, policies, requestUnit(c))
+		if err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderLocation, "/api/v1/loads/"+job.ID)
+		return c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// DiffJSON compares a list of tracks and their associated signals with the stored data.
+// With apply=true the stored data is changed to match the list exactly, deletions included.
+func DiffJSON(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		apply, _ := strconv.ParseBool(c.QueryParam("apply"))
+
+		dec := application.NewTrackSignalsDecoder(c.Request().Body)
+		dec.Unit = requestUnit(c)
+		diff, err := s.DiffTrackSignals(c.Request().Context(), dec, apply)
+		if err != nil {
+			return err
 		}
 
-		if err := s.LoadTrackSignals(c.Request().Context(), input); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(http.StatusOK, diff)
+	}
+}
+
+// GetLoadJobHandler reports the state and progress of a load job.
+func GetLoadJobHandler(jobs *application.LoadJobs) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		job, err := jobs.Get(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, job)
+	}
+}
+
+// CancelLoadJobHandler cancels a queued or running load job.
+func CancelLoadJobHandler(jobs *application.LoadJobs) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		job, err := jobs.Cancel(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return err
 		}
 
-		return c.NoContent(http.StatusCreated)
+		return c.JSON(http.StatusAccepted, job)
 	}
 }
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateELRHandler registers an ELR, an existing ELR is a conflict unless on_conflict says otherwise.
+func CreateELRHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var elr domain.ELR
+		if err := c.Bind(&elr); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		policy := domain.ConflictFail
+		if name := c.QueryParam("on_conflict"); name != "" {
+			var err error
+			if policy, err = domain.ParseConflictPolicy(name); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+
+		outcome, err := s.CreateELR(c.Request().Context(), &elr, policy)
+		if err != nil {
+			return err
+		}
+
+		if outcome != domain.WriteInserted {
+			return c.JSON(http.StatusOK, elr)
+		}
+		return c.JSON(http.StatusCreated, elr)
+	}
+}
+
+// GetELRHandler returns a registered ELR.
+func GetELRHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		elr, err := s.GetELR(c.Request().Context(), c.Param("code"))
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, elr)
+	}
+}
+
+// ListELRsHandler lists the registered ELRs ordered by code.
+func ListELRsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		elrs, nextPage, err := s.ListELRs(c.Request().Context(), limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"elrs":      elrs,
+			"next_page": nextPage,
+		})
+	}
+}
+
+// UpdateELRHandler replaces the details of a registered ELR.
+func UpdateELRHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var elr domain.ELR
+		if err := c.Bind(&elr); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		if err := s.UpdateELR(c.Request().Context(), c.Param("code"), &elr); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, elr)
+	}
+}
+
+// DeleteELRHandler removes an ELR that no signal references.
+func DeleteELRHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := s.DeleteELR(c.Request().Context(), c.Param("code")); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
+	}
+}
+
+// ListELRSignalsHandler lists the signals on an ELR in mileage order.
+func ListELRSignalsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		signals, nextPage, err := s.ListELRSignals(c.Request().Context(), c.Param("code"), limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"signals":   signals,
+			"next_page": nextPage,
+		})
+	}
+}
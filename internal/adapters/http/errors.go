@@ -0,0 +1,148 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// Error codes sent in the body of every error response.
+// They are stable so clients can switch on them rather than on the message.
+const (
+	CodeBadRequest   = 1000
+	CodeInvalidInput = 1001
+	CodeValidation   = 1002
+	CodeLoadRejected = 1003
+
+	CodeNotFound        = 2000
+	CodeSignalNotFound  = 2001
+	CodeTrackNotFound   = 2002
+	CodeMileageNotFound = 2003
+	CodeLoadJobNotFound = 2004
+	CodeNoPath          = 2005
+	CodeELRNotFound     = 2006
+	CodeRouteNotFound   = 2007
+	CodePointNotFound   = 2008
+
+	CodeConflict        = 3000
+	CodeSignalConflict  = 3001
+	CodeTrackConflict   = 3002
+	CodeMileageConflict = 3003
+	CodeLoadJobConflict = 3004
+	CodeELRConflict     = 3005
+	CodeRouteConflict   = 3006
+	CodePointConflict   = 3007
+
+	CodeInternal    = 5000
+	CodeUnavailable = 5003
+)
+
+var (
+	notFoundCodes = map[domain.Entity]int{
+		domain.EntitySignal:  CodeSignalNotFound,
+		domain.EntityTrack:   CodeTrackNotFound,
+		domain.EntityMileage: CodeMileageNotFound,
+		domain.EntityLoadJob: CodeLoadJobNotFound,
+		domain.EntityELR:     CodeELRNotFound,
+		domain.EntityRoute:   CodeRouteNotFound,
+		domain.EntityPoint:   CodePointNotFound,
+	}
+	conflictCodes = map[domain.Entity]int{
+		domain.EntitySignal:  CodeSignalConflict,
+		domain.EntityTrack:   CodeTrackConflict,
+		domain.EntityMileage: CodeMileageConflict,
+		domain.EntityLoadJob: CodeLoadJobConflict,
+		domain.EntityELR:     CodeELRConflict,
+		domain.EntityRoute:   CodeRouteConflict,
+		domain.EntityPoint:   CodePointConflict,
+	}
+)
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Error  string              `json:"error"`
+	Code   int                 `json:"code"`
+	Fields []domain.FieldError `json:"fields,omitempty"`
+	Issues []domain.LoadIssue  `json:"issues,omitempty"`
+}
+
+// ErrorHandler renders the errors returned by handlers, it replaces Echo's default handler.
+// Domain errors get their status and code from the catalogue, anything unknown is logged and hidden behind a 500.
+func ErrorHandler(logger *logrus.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		status, body := errorResponseFor(err)
+		if status >= http.StatusInternalServerError {
+			logger.WithContext(c.Request().Context()).WithError(err).Error("Request failed")
+		}
+
+		if err := c.JSON(status, body); err != nil {
+			logger.WithContext(c.Request().Context()).WithError(err).Error("Writing error response")
+		}
+	}
+}
+
+// errorResponseFor picks the status and body for an error.
+func errorResponseFor(err error) (int, errorResponse) {
+	var (
+		loadErr       *application.LoadValidationError
+		validationErr *domain.ValidationError
+		notFoundErr   *domain.NotFoundError
+		conflictErr   *domain.ConflictError
+		httpErr       *echo.HTTPError
+	)
+
+	switch {
+	case errors.As(err, &loadErr):
+		return http.StatusUnprocessableEntity, errorResponse{Error: loadErr.Error(), Code: CodeLoadRejected, Issues: loadErr.Issues}
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest, errorResponse{Error: validationErr.Error(), Code: CodeValidation, Fields: validationErr.Fields}
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound, errorResponse{Error: notFoundErr.Error(), Code: codeOr(notFoundCodes, notFoundErr.Entity, CodeNotFound)}
+	case errors.As(err, &conflictErr):
+		return http.StatusConflict, errorResponse{Error: conflictErr.Error(), Code: codeOr(conflictCodes, conflictErr.Entity, CodeConflict)}
+	case errors.Is(err, application.ErrNoPath):
+		return http.StatusNotFound, errorResponse{Error: err.Error(), Code: CodeNoPath}
+	case errors.Is(err, application.ErrLoadQueueFull):
+		return http.StatusServiceUnavailable, errorResponse{Error: err.Error(), Code: CodeUnavailable}
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, errorResponse{Error: err.Error(), Code: CodeNotFound}
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, errorResponse{Error: err.Error(), Code: CodeConflict}
+	case errors.As(err, &httpErr):
+		return httpErr.Code, errorResponse{Error: fmt.Sprint(httpErr.Message), Code: httpErrorCode(httpErr.Code)}
+	default:
+		return http.StatusInternalServerError, errorResponse{Error: "Internal server error", Code: CodeInternal}
+	}
+}
+
+// httpErrorCode maps the status of an echo.HTTPError, such as an unknown route or a bad request body, to a code.
+func httpErrorCode(status int) int {
+	switch {
+	case status == http.StatusBadRequest:
+		return CodeInvalidInput
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case status >= http.StatusInternalServerError:
+		return CodeInternal
+	default:
+		return CodeBadRequest
+	}
+}
+
+func codeOr(codes map[domain.Entity]int, entity domain.Entity, fallback int) int {
+	if code, ok := codes[entity]; ok {
+		return code
+	}
+	return fallback
+}
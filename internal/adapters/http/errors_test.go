@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/adapters/http"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := map[string]struct {
+		err error
+
+		wantStatus int
+		wantBody   map[string]any
+	}{
+		"missing signal": {
+			err:        fmt.Errorf("getting signal: %w", &domain.NotFoundError{Entity: domain.EntitySignal, Key: "12"}),
+			wantStatus: nethttp.StatusNotFound,
+			wantBody:   map[string]any{"error": "signal 12 not found", "code": float64(http.CodeSignalNotFound)},
+		},
+		"mileage conflict": {
+			err:        &domain.ConflictError{Entity: domain.EntityMileage, Reason: "signal 1 already has a different mileage on track 2"},
+			wantStatus: nethttp.StatusConflict,
+			wantBody:   map[string]any{"error": "signal 1 already has a different mileage on track 2", "code": float64(http.CodeMileageConflict)},
+		},
+		"invalid field": {
+			err:        domain.Invalid("elr", "is required"),
+			wantStatus: nethttp.StatusBadRequest,
+			wantBody: map[string]any{
+				"error":  "validation failed: elr is required",
+				"code":   float64(http.CodeValidation),
+				"fields": []any{map[string]any{"field": "elr", "reason": "is required"}},
+			},
+		},
+		"bad request": {
+			err:        echo.NewHTTPError(nethttp.StatusBadRequest, "Invalid signal ID"),
+			wantStatus: nethttp.StatusBadRequest,
+			wantBody:   map[string]any{"error": "Invalid signal ID", "code": float64(http.CodeInvalidInput)},
+		},
+		"unknown route": {
+			err:        echo.ErrNotFound,
+			wantStatus: nethttp.StatusNotFound,
+			wantBody:   map[string]any{"error": "Not Found", "code": float64(http.CodeNotFound)},
+		},
+		"no path": {
+			err:        fmt.Errorf("from %q to %q: %w", "A", "Z", application.ErrNoPath),
+			wantStatus: nethttp.StatusNotFound,
+			wantBody:   map[string]any{"error": `from "A" to "Z": no path between locations`, "code": float64(http.CodeNoPath)},
+		},
+		"unknown error is hidden": {
+			err:        errors.New("connection refused"),
+			wantStatus: nethttp.StatusInternalServerError,
+			wantBody:   map[string]any{"error": "Internal server error", "code": float64(http.CodeInternal)},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	handler := http.ErrorHandler(logger)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(nethttp.MethodGet, "/", nil), rec)
+
+			handler(test.err, c)
+
+			assert.Equal(t, test.wantStatus, rec.Code, "status")
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), "decoding body")
+			assert.Equal(t, test.wantBody, body, "body")
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// routeID parses the route ID from the path.
+func routeID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid route ID")
+	}
+	return id, nil
+}
+
+// CreateInterlockingRouteHandler stores a new interlocking route, it starts out released.
+func CreateInterlockingRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var route domain.Route
+		if err := c.Bind(&route); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		if err := s.CreateRoute(c.Request().Context(), &route); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, route)
+	}
+}
+
+// GetInterlockingRouteHandler returns an interlocking route with its state.
+func GetInterlockingRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := routeID(c)
+		if err != nil {
+			return err
+		}
+
+		route, err := s.GetRoute(c.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, route)
+	}
+}
+
+// ListInterlockingRoutesHandler lists the interlocking routes ordered by ID, optionally only those in a state.
+func ListInterlockingRoutesHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		var state domain.RouteState
+		if name := c.QueryParam("state"); name != "" {
+			if state, err = domain.ParseRouteState(name); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+
+		routes, nextPage, err := s.ListRoutes(c.Request().Context(), state, limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"routes":    routes,
+			"next_page": nextPage,
+		})
+	}
+}
+
+// DeleteInterlockingRouteHandler removes an interlocking route that isn't set.
+func DeleteInterlockingRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := routeID(c)
+		if err != nil {
+			return err
+		}
+
+		if err := s.DeleteRoute(c.Request().Context(), id); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
+	}
+}
+
+// SetInterlockingRouteHandler sets an interlocking route, a route that conflicts with one already set is refused.
+func SetInterlockingRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := routeID(c)
+		if err != nil {
+			return err
+		}
+
+		route, err := s.SetRoute(c.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, route)
+	}
+}
+
+// ReleaseInterlockingRouteHandler releases a set interlocking route.
+func ReleaseInterlockingRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := routeID(c)
+		if err != nil {
+			return err
+		}
+
+		route, err := s.ReleaseRoute(c.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, route)
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// mileageParams parses the track and signal IDs of a mileage from the path.
+func mileageParams(c echo.Context) (trackID, signalID int, err error) {
+	trackID, err = strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+	}
+
+	signalID, err = strconv.Atoi(c.Param("signal_id"))
+	if err != nil {
+		return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
+	}
+
+	return trackID, signalID, nil
+}
+
+// pagination parses the limit and page query parameters, the limit defaults to 100.
+func pagination(c echo.Context) (limit, page int, err error) {
+	limit = 100
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 0 {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination limit value")
+		}
+	}
+
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		if page, err = strconv.Atoi(pageStr); err != nil || page < 0 {
+			return 0, 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination page value")
+		}
+	}
+
+	return limit, page, nil
+}
+
+// GetMileageHandler returns the mileage of a signal on a track.
+func GetMileageHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, signalID, err := mileageParams(c)
+		if err != nil {
+			return err
+		}
+
+		mileage, err := s.GetMileage(c.Request().Context(), signalID, trackID)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, mileage)
+	}
+}
+
+// SetMileageHandler places a signal on a track, or moves it if it is already on the track.
+func SetMileageHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, signalID, err := mileageParams(c)
+		if err != nil {
+			return err
+		}
+
+		var mileage domain.Mileage
+		if err := c.Bind(&mileage); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		outcome, err := s.SetMileage(c.Request().Context(), signalID, trackID, &mileage)
+		if err != nil {
+			return err
+		}
+
+		if outcome == domain.WriteInserted {
+			return c.JSON(http.StatusCreated, mileage)
+		}
+		return c.JSON(http.StatusOK, mileage)
+	}
+}
+
+// DeleteMileageHandler removes a signal from a track.
+func DeleteMileageHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, signalID, err := mileageParams(c)
+		if err != nil {
+			return err
+		}
+
+		err = s.DeleteMileage(c.Request().Context(), signalID, trackID)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
+	}
+}
+
+// ListTrackMileagesHandler lists the mileages on a track ordered by mileage.
+func ListTrackMileagesHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+		}
+
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		mileages, nextPage, err := s.ListTrackMileages(c.Request().Context(), trackID, limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"mileages":  mileages,
+			"next_page": nextPage,
+		})
+	}
+}
+
+// ListSignalMileagesHandler lists the mileages of a signal on every track it is on.
+func ListSignalMileagesHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		signalID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
+		}
+
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		mileages, nextPage, err := s.ListSignalMileages(c.Request().Context(), signalID, limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"mileages":  mileages,
+			"next_page": nextPage,
+		})
+	}
+}
+
+// NextSignalHandler returns the signal after a signal on a track in the direction of travel.
+// With connected=true the search carries on onto the tracks joined to the end of the track.
+func NextSignalHandler(s *application.Service) echo.HandlerFunc {
+	return adjacentSignalsHandler(s.NextSignals)
+}
+
+// PreviousSignalHandler returns the signal before a signal on a track in the direction of travel.
+// With connected=true the search carries on onto the tracks joined to the start of the track.
+func PreviousSignalHandler(s *application.Service) echo.HandlerFunc {
+	return adjacentSignalsHandler(s.PreviousSignals)
+}
+
+func adjacentSignalsHandler(find func(ctx context.Context, trackID, signalID int, direction domain.Direction, connected bool) ([]domain.AdjacentSignal, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, signalID, err := mileageParams(c)
+		if err != nil {
+			return err
+		}
+
+		direction := domain.Direction(c.QueryParam("direction"))
+		switch direction {
+		case "":
+			direction = domain.DirectionDown
+		case domain.DirectionDown, domain.DirectionUp:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid direction, must be up or down")
+		}
+
+		var connected bool
+		if connectedStr := c.QueryParam("connected"); connectedStr != "" {
+			if connected, err = strconv.ParseBool(connectedStr); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid connected value")
+			}
+		}
+
+		signals, err := find(c.Request().Context(), trackID, signalID, direction, connected)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{"signals": signals})
+	}
+}
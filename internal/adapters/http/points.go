@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// pointID parses the point ID from the path.
+func pointID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid point ID")
+	}
+	return id, nil
+}
+
+// CreatePointHandler stores a new point, it is set normal unless a position is given.
+func CreatePointHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var point domain.Point
+		if err := c.Bind(&point); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		if err := s.CreatePoint(c.Request().Context(), &point); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusCreated, point)
+	}
+}
+
+// GetPointHandler returns a point with its position.
+func GetPointHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := pointID(c)
+		if err != nil {
+			return err
+		}
+
+		point, err := s.GetPoint(c.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, point)
+	}
+}
+
+// ListPointsHandler lists the points ordered by ID, optionally only those at a location.
+func ListPointsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, page, err := pagination(c)
+		if err != nil {
+			return err
+		}
+
+		points, nextPage, err := s.ListPoints(c.Request().Context(), c.QueryParam("location"), limit, page)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"points":    points,
+			"next_page": nextPage,
+		})
+	}
+}
+
+// UpdatePointHandler replaces a point, changing its position switches it.
+func UpdatePointHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := pointID(c)
+		if err != nil {
+			return err
+		}
+
+		var point domain.Point
+		if err := c.Bind(&point); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		if err := s.UpdatePoint(c.Request().Context(), id, &point); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, point)
+	}
+}
+
+// DeletePointHandler removes a point.
+func DeletePointHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := pointID(c)
+		if err != nil {
+			return err
+		}
+
+		if err := s.DeletePoint(c.Request().Context(), id); err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
+	}
+}
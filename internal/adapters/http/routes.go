@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+)
+
+// FindRouteHandler returns the shortest sequence of tracks between two locations.
+func FindRouteHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		from, to := c.QueryParam("from"), c.QueryParam("to")
+		if from == "" || to == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Both from and to locations are required")
+		}
+
+		weight := application.PathWeight(c.QueryParam("weight"))
+		if weight != "" && weight != application.WeightHops && weight != application.WeightLength {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid weight, must be hops or length")
+		}
+
+		path, err := s.FindPath(c.Request().Context(), from, to, weight)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, path)
+	}
+}
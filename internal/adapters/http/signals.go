@@ -9,17 +9,30 @@ import (
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
+// CreateSignalHandler creates a signal, an existing signal is a conflict unless on_conflict says otherwise.
 func CreateSignalHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var signal domain.Signal
 		if err := c.Bind(&signal); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 		}
 
-		if err := s.CreateSignal(c.Request().Context(), &signal); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		policy := domain.ConflictFail
+		if name := c.QueryParam("on_conflict"); name != "" {
+			var err error
+			if policy, err = domain.ParseConflictPolicy(name); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+
+		outcome, err := s.CreateSignal(c.Request().Context(), &signal, policy)
+		if err != nil {
+			return err
 		}
 
+		if outcome != domain.WriteInserted {
+			return c.JSON(http.StatusOK, signal)
+		}
 		return c.JSON(http.StatusCreated, signal)
 	}
 }
@@ -28,17 +41,17 @@ func GetSignalHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		signalIDstr := c.Param("id")
 		if signalIDstr == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Empty signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty signal ID")
 		}
 
 		signalID, err := strconv.Atoi(signalIDstr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
 		}
 
 		signal, err := s.GetSignal(c.Request().Context(), signalID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to get signal"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, signal)
@@ -49,17 +62,17 @@ func GetSignalTracks(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		signalIDstr := c.Param("id")
 		if signalIDstr == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Empty signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty signal ID")
 		}
 
 		signalID, err := strconv.Atoi(signalIDstr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
 		}
 
 		tracks, _, err := s.GetSignalTracks(c.Request().Context(), signalID, 100, 0)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list signal tracks"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, tracks)
@@ -73,7 +86,7 @@ func ListSignalHandler(s *application.Service) echo.HandlerFunc {
 		if pageStr := c.Param("page"); pageStr != "" {
 			p, err := strconv.Atoi(pageStr)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid pagination page value"})
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination page value")
 			}
 			page = p
 		}
@@ -81,14 +94,19 @@ func ListSignalHandler(s *application.Service) echo.HandlerFunc {
 		if limitStr := c.Param("limit"); limitStr != "" {
 			l, err := strconv.Atoi(limitStr)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid pagination limit value"})
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination limit value")
 			}
 			limit = l
 		}
 
-		signals, nextPage, err := s.ListSignals(c.Request().Context(), limit, page)
+		filter, err := signalFilter(c)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list signal"})
+			return err
+		}
+
+		signals, nextPage, err := s.ListSignals(c.Request().Context(), filter, limit, page)
+		if err != nil {
+			return err
 		}
 
 		return c.JSON(http.StatusOK, map[string]any{
@@ -98,15 +116,46 @@ func ListSignalHandler(s *application.Service) echo.HandlerFunc {
 	}
 }
 
+// signalFilter reads the signal attributes to filter on from the query, unset ones match every signal.
+func signalFilter(c echo.Context) (domain.SignalFilter, error) {
+	filter := domain.SignalFilter{
+		Type:           domain.SignalType(c.QueryParam("type")),
+		RouteIndicator: domain.RouteIndicator(c.QueryParam("route_indicator")),
+	}
+
+	if aspectsStr := c.QueryParam("aspects"); aspectsStr != "" {
+		aspects, err := strconv.Atoi(aspectsStr)
+		if err != nil {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "Invalid aspects value")
+		}
+		filter.Aspects = aspects
+	}
+
+	if automaticStr := c.QueryParam("automatic"); automaticStr != "" {
+		automatic, err := strconv.ParseBool(automaticStr)
+		if err != nil {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "Invalid automatic value, must be true or false")
+		}
+		filter.Automatic = &automatic
+	}
+
+	return filter, nil
+}
+
 func UpdateSignalHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		signalID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
+		}
+
 		var signal domain.Signal
 		if err := c.Bind(&signal); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 		}
 
-		if err := s.UpdateSignal(c.Request().Context(), &signal); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update signal"})
+		if err := s.UpdateSignal(c.Request().Context(), signalID, &signal); err != nil {
+			return err
 		}
 
 		return c.JSON(http.StatusOK, signal)
@@ -117,19 +166,63 @@ func DeleteSignalHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		signalIDstr := c.Param("id")
 		if signalIDstr == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Empty signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty signal ID")
 		}
 
 		signalID, err := strconv.Atoi(signalIDstr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid signal ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid signal ID")
 		}
 
 		err = s.DeleteSignal(c.Request().Context(), signalID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete signal"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
 	}
 }
+
+// NearestSignalsHandler finds the signals on an ELR nearest a mileage, in one direction or both.
+// The mileage is read in the unit of the request, or as text such as 23m 40ch.
+func NearestSignalsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code := c.QueryParam("elr")
+		if code == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty elr")
+		}
+
+		if c.QueryParam("mileage") == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty mileage")
+		}
+		mileage, err := domain.ParseDistance(c.QueryParam("mileage"), requestUnit(c))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid mileage: "+err.Error())
+		}
+
+		direction := domain.Direction(c.QueryParam("direction"))
+		switch direction {
+		case "", domain.DirectionDown, domain.DirectionUp:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid direction, must be up or down")
+		}
+
+		limit := 10
+		if limitStr := c.QueryParam("limit"); limitStr != "" {
+			if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 || limit > 100 {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid limit value, must be between 1 and 100")
+			}
+		}
+
+		signals, err := s.NearestSignals(c.Request().Context(), code, mileage, direction, limit)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]any{
+			"elr":     code,
+			"mileage": mileage,
+			"signals": signals,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// SimulateAspectsHandler works out the aspects of the signals on a track for the occupied block sections
+// in the body, in the direction of travel, down by default.
+func SimulateAspectsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trackID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+		}
+
+		direction := domain.Direction(c.QueryParam("direction"))
+		switch direction {
+		case "":
+			direction = domain.DirectionDown
+		case domain.DirectionDown, domain.DirectionUp:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid direction, must be up or down")
+		}
+
+		var occupancy domain.Occupancy
+		if err := c.Bind(&occupancy); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		simulation, err := s.SimulateAspects(c.Request().Context(), trackID, direction, occupancy)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, simulation)
+	}
+}
+
+// SimulateMovementsHandler runs the trains in the body through a movement simulation and returns its timeline.
+func SimulateMovementsHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var plan domain.MovementPlan
+		if err := c.Bind(&plan); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
+		}
+
+		log, err := s.SimulateMovements(c.Request().Context(), plan)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, log)
+	}
+}
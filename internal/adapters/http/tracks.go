@@ -11,13 +11,23 @@ import (
 
 func CreateTrackHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		var track domain.Track
-		if err := c.Bind(&track); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+		var input domain.TrackSignals
+		if err := c.Bind(&input); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 		}
 
-		if err := s.CreateTrack(c.Request().Context(), &track); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create track"})
+		policies, err := conflictPolicies(c, domain.ConflictPolicies{
+			Tracks:   domain.ConflictFail,
+			Signals:  domain.ConflictFail,
+			Mileages: domain.ConflictFail,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		track, err := s.CreateTrackSignals(c.Request().Context(), &input, policies)
+		if err != nil {
+			return err
 		}
 
 		return c.JSON(http.StatusCreated, track)
@@ -28,17 +38,26 @@ func GetTrackHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		trackIDStr := c.Param("id")
 		if trackIDStr == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Empty track ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty track ID")
 		}
 
 		trackID, err := strconv.Atoi(trackIDStr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid track ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+		}
+
+		direction := domain.Direction(c.QueryParam("direction"))
+		switch direction {
+		case "":
+			direction = domain.DirectionDown
+		case domain.DirectionDown, domain.DirectionUp:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid direction, must be up or down")
 		}
 
-		track, err := s.GetTrack(c.Request().Context(), trackID)
+		track, err := s.GetTrackSignals(c.Request().Context(), trackID, direction)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create track"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, track)
@@ -52,7 +71,7 @@ func ListTrackHandler(s *application.Service) echo.HandlerFunc {
 		if pageStr := c.Param("page"); pageStr != "" {
 			p, err := strconv.Atoi(pageStr)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid pagination page value"})
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination page value")
 			}
 			page = p
 		}
@@ -60,14 +79,14 @@ func ListTrackHandler(s *application.Service) echo.HandlerFunc {
 		if limitStr := c.Param("limit"); limitStr != "" {
 			l, err := strconv.Atoi(limitStr)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid pagination limit value"})
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid pagination limit value")
 			}
 			limit = l
 		}
 
 		tracks, nextPage, err := s.ListTracks(c.Request().Context(), limit, page)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to list tracks"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, map[string]any{
@@ -77,15 +96,39 @@ func ListTrackHandler(s *application.Service) echo.HandlerFunc {
 	}
 }
 
+// ExportTracksHandler streams the ELRs, the signals on no track and every track with its signals in a format accepted by LoadJSON.
+func ExportTracksHandler(s *application.Service) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="tracks.json"`)
+
+		err := s.ExportTrackSignals(c.Request().Context(), c.Response(), requestUnit(c))
+		if err != nil && !c.Response().Committed {
+			return err
+		}
+		if err != nil {
+			// The status has already been sent, the truncated export tells the client it failed.
+			s.Logger.WithContext(c.Request().Context()).WithError(err).Error("Failed to export tracks part way through")
+		}
+
+		return nil
+	}
+}
+
 func UpdateTrackHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		trackID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
+		}
+
 		var track domain.Track
 		if err := c.Bind(&track); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request payload"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request payload")
 		}
 
-		if err := s.UpdateTrack(c.Request().Context(), &track); err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update track"})
+		if err := s.UpdateTrack(c.Request().Context(), trackID, &track); err != nil {
+			return err
 		}
 
 		return c.JSON(http.StatusOK, track)
@@ -96,17 +139,17 @@ func DeleteTrackHandler(s *application.Service) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		trackIDstr := c.Param("id")
 		if trackIDstr == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Empty track ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Empty track ID")
 		}
 
 		trackID, err := strconv.Atoi(trackIDstr)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid track ID"})
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid track ID")
 		}
 
 		err = s.DeleteTrack(c.Request().Context(), trackID)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete track"})
+			return err
 		}
 
 		return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
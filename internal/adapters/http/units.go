@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// unitKey is the context key the distance unit of a request is stored under.
+const unitKey = "unit"
+
+// Units reads the unit query parameter, the unit distances in the request and response bodies are written in.
+// Distances are in decimal miles when it is missing.
+func Units() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			unit := domain.UnitMiles
+			if name := c.QueryParam("unit"); name != "" {
+				var err error
+				if unit, err = domain.ParseUnit(name); err != nil {
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+				}
+			}
+			c.Set(unitKey, unit)
+
+			return next(c)
+		}
+	}
+}
+
+// requestUnit returns the unit read by Units, decimal miles if it hasn't run.
+func requestUnit(c echo.Context) domain.Unit {
+	if unit, ok := c.Get(unitKey).(domain.Unit); ok {
+		return unit
+	}
+	return domain.UnitMiles
+}
+
+// JSONSerializer encodes and decodes JSON bodies with their distances in the unit of the request.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(c echo.Context, i any, indent string) error {
+	data, err := application.MarshalJSONIn(i, requestUnit(c))
+	if err != nil {
+		return err
+	}
+	if indent != "" {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", indent); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	_, err = c.Response().Write(append(data, '\n'))
+	return err
+}
+
+func (JSONSerializer) Deserialize(c echo.Context, i any) error {
+	data, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+
+	err = application.UnmarshalJSONIn(data, i, requestUnit(c))
+	if ute, ok := err.(*json.UnmarshalTypeError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
+	} else if se, ok := err.(*json.SyntaxError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error())).SetInternal(err)
+	}
+	return err
+}
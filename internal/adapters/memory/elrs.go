@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateELR inserts a new ELR.
+// When an ELR with the same code exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *Repository) CreateELR(ctx context.Context, elr *domain.ELR, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	if err := checkELR(elr); err != nil {
+		return "", fmt.Errorf("inserting elr: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.elrs[elr.Code]
+	if !ok {
+		r.elrs[elr.Code] = *elr
+		return domain.WriteInserted, nil
+	}
+
+	outcome, err := policy.Resolve(existing == *elr)
+	if err != nil {
+		return "", &domain.ConflictError{Entity: domain.EntityELR, Reason: fmt.Sprintf("elr %s already exists with different details", elr.Code)}
+	}
+	if outcome == domain.WriteUpdated {
+		r.elrs[elr.Code] = *elr
+	}
+
+	return outcome, nil
+}
+
+// GetELR retrieves an ELR by its code.
+func (r *Repository) GetELR(ctx context.Context, code string) (*domain.ELR, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	elr, ok := r.elrs[code]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityELR, Key: code}
+	}
+
+	return &elr, nil
+}
+
+// ListELRs retrieves all ELRs ordered by code.
+// Handles paginated requests and returns the total count along with the returned ELRs.
+func (r *Repository) ListELRs(ctx context.Context, limit, page int) ([]domain.ELR, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	elrs := make([]domain.ELR, 0, len(r.elrs))
+	for _, elr := range r.elrs {
+		elrs = append(elrs, elr)
+	}
+	slices.SortFunc(elrs, func(a, b domain.ELR) int {
+		return cmp.Compare(a.Code, b.Code)
+	})
+
+	return paginate(elrs, limit, page), len(elrs), nil
+}
+
+// UpdateELR modifies an existing ELR.
+func (r *Repository) UpdateELR(ctx context.Context, elr *domain.ELR) error {
+	if err := checkELR(elr); err != nil {
+		return fmt.Errorf("updating elr: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.elrs[elr.Code]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityELR, Key: elr.Code}
+	}
+	r.elrs[elr.Code] = *elr
+
+	return nil
+}
+
+// DeleteELR removes an ELR, it fails while signals still reference it and returns domain.ErrNotFound if it doesn't exist.
+func (r *Repository) DeleteELR(ctx context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.elrs[code]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityELR, Key: code}
+	}
+	for _, signal := range r.signals {
+		if signal.ELR == code {
+			return &domain.ConflictError{Entity: domain.EntityELR, Reason: fmt.Sprintf("elr %s is still referenced by signals", code)}
+		}
+	}
+
+	delete(r.elrs, code)
+
+	return nil
+}
+
+// ListELRSignals retrieves the signals on the ELR that are on a track, ordered by mileage, track and signal ID.
+// Handles paginated requests and returns the total count along with the returned signals.
+func (r *Repository) ListELRSignals(ctx context.Context, code string, limit, page int) ([]domain.ELRSignal, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	signals := []domain.ELRSignal{}
+	for key, m := range r.mileages {
+		signal := r.signals[key.signalID]
+		if signal.ELR != code {
+			continue
+		}
+
+		mileage := m.Mileage
+		signals = append(signals, domain.ELRSignal{
+			TrackID: key.trackID,
+			TrackSignal: domain.TrackSignal{
+				ID:         signal.ID,
+				Name:       signal.Name,
+				ELR:        signal.ELR,
+				Mileage:    &mileage,
+				SignalKind: signal.SignalKind,
+			},
+		})
+	}
+	slices.SortFunc(signals, func(a, b domain.ELRSignal) int {
+		return cmp.Or(
+			cmp.Compare(*a.Mileage, *b.Mileage),
+			cmp.Compare(a.TrackID, b.TrackID),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	return paginate(signals, limit, page), len(signals), nil
+}
+
+// checkELR enforces the same constraints as the elrs table.
+func checkELR(elr *domain.ELR) error {
+	switch {
+	case elr.Code == "":
+		return domain.Invalid("code", "is required")
+	case len(elr.Code) > 4:
+		return domain.Invalid("code", "is longer than 4 characters")
+	case len(elr.Description) > 255:
+		return domain.Invalid("description", "is longer than 255 characters")
+	case len(elr.StartLocation) > 255:
+		return domain.Invalid("start_location", "is longer than 255 characters")
+	case len(elr.EndLocation) > 255:
+		return domain.Invalid("end_location", "is longer than 255 characters")
+	}
+
+	return nil
+}
+
+// ListSignalsNear retrieves the signals on the ELR from the mileage onwards in the direction, nearest first.
+// Ties are ordered by track and signal ID.
+func (r *Repository) ListSignalsNear(ctx context.Context, code string, mileage domain.Distance, direction domain.Direction, limit int) ([]domain.NearbySignal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	signals := []domain.NearbySignal{}
+	for key, m := range r.mileages {
+		signal := r.signals[key.signalID]
+		if signal.ELR != code {
+			continue
+		}
+
+		// Mileages increase in the down direction.
+		distance := m.Mileage - mileage
+		if direction == domain.DirectionUp {
+			distance = -distance
+		}
+		if distance < 0 {
+			continue
+		}
+
+		signalMileage := m.Mileage
+		signals = append(signals, domain.NearbySignal{
+			ELRSignal: domain.ELRSignal{
+				TrackID: key.trackID,
+				TrackSignal: domain.TrackSignal{
+					ID:         signal.ID,
+					Name:       signal.Name,
+					ELR:        signal.ELR,
+					Mileage:    &signalMileage,
+					SignalKind: signal.SignalKind,
+				},
+			},
+			Distance:  distance,
+			Direction: direction,
+		})
+	}
+	slices.SortFunc(signals, func(a, b domain.NearbySignal) int {
+		return cmp.Or(
+			cmp.Compare(a.Distance, b.Distance),
+			cmp.Compare(a.TrackID, b.TrackID),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	return paginate(signals, limit, 0), nil
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// Repository is a thread-safe in-memory store.
+// It mirrors the behaviour of the PostgreSQL schema so the API can run without a database,
+// it is meant for tests and development rather than production data.
+type Repository struct {
+	mu sync.RWMutex
+
+	signals  map[int]domain.Signal
+	tracks   map[int]domain.Track
+	mileages map[mileageKey]domain.Mileage
+	elrs     map[string]domain.ELR
+	routes   map[int]domain.Route
+	points   map[int]domain.Point
+}
+
+type mileageKey struct {
+	signalID int
+	trackID  int
+}
+
+// NewRepository initializes a new empty in-memory repository.
+func NewRepository() *Repository {
+	return &Repository{
+		signals:  make(map[int]domain.Signal),
+		tracks:   make(map[int]domain.Track),
+		mileages: make(map[mileageKey]domain.Mileage),
+		elrs:     make(map[string]domain.ELR),
+		routes:   make(map[int]domain.Route),
+		points:   make(map[int]domain.Point),
+	}
+}
+
+// InTransaction calls fn with a copy of the repository and keeps the copy's data when fn returns nil.
+// The repository is locked for the duration of the transaction so transactions are serialized.
+func (r *Repository) InTransaction(ctx context.Context, fn func(ctx context.Context, tx domain.Tx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &Repository{
+		signals:  maps.Clone(r.signals),
+		tracks:   maps.Clone(r.tracks),
+		mileages: maps.Clone(r.mileages),
+		elrs:     maps.Clone(r.elrs),
+		routes:   maps.Clone(r.routes),
+		points:   maps.Clone(r.points),
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	r.signals, r.tracks, r.mileages, r.elrs, r.routes, r.points = tx.signals, tx.tracks, tx.mileages, tx.elrs, tx.routes, tx.points
+
+	return nil
+}
+
+// InSnapshot calls fn with a read-only view of the repository, writes wait until fn returns.
+func (r *Repository) InSnapshot(ctx context.Context, fn func(ctx context.Context, tx domain.Tx) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// The view shares the maps rather than copying them, it has a lock of its own so reading
+	// through it doesn't take the read lock again.
+	return fn(ctx, &Repository{
+		signals:  r.signals,
+		tracks:   r.tracks,
+		mileages: r.mileages,
+		elrs:     r.elrs,
+		routes:   r.routes,
+		points:   r.points,
+	})
+}
+
+// paginate returns the requested page of items, a zero limit returns everything after the offset.
+func paginate[T any](items []T, limit, page int) []T {
+	offset := page * limit
+	if offset > len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+// sortedValues returns the values of the map ordered by key.
+func sortedValues[T any](m map[int]T) []T {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	values := make([]T, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+
+	return values
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/warrenb95/railway-signals/internal/adapters/memory"
+	"github.com/warrenb95/railway-signals/internal/adapters/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		repo := memory.NewRepository()
+		return storetest.Stores{Signals: repo, Tracks: repo, Mileages: repo, ELRs: repo, Routes: repo, Points: repo, Transactor: repo}
+	})
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// AddMileage adds a mileage value linking a signal with a track.
+// When the signal already has a mileage on the track the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *Repository) AddMileage(ctx context.Context, mileage *domain.Mileage, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.signals[mileage.SignalID]; !ok {
+		return "", &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(mileage.SignalID)}
+	}
+	if _, ok := r.tracks[mileage.TrackID]; !ok {
+		return "", &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(mileage.TrackID)}
+	}
+
+	key := mileageKey{signalID: mileage.SignalID, trackID: mileage.TrackID}
+	existing, ok := r.mileages[key]
+	if !ok {
+		r.mileages[key] = *mileage
+		return domain.WriteInserted, nil
+	}
+
+	outcome, err := policy.Resolve(existing == *mileage)
+	if err != nil {
+		return "", &domain.ConflictError{Entity: domain.EntityMileage, Reason: fmt.Sprintf("signal %d already has a different mileage on track %d", mileage.SignalID, mileage.TrackID)}
+	}
+	if outcome == domain.WriteUpdated {
+		r.mileages[key] = *mileage
+	}
+
+	return outcome, nil
+}
+
+// GetMileage retrieves the mileage of a signal on a track.
+func (r *Repository) GetMileage(ctx context.Context, signalID, trackID int) (*domain.Mileage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mileage, ok := r.mileages[mileageKey{signalID: signalID, trackID: trackID}]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityMileage, Key: fmt.Sprintf("of signal %d on track %d", signalID, trackID)}
+	}
+
+	return &mileage, nil
+}
+
+// DeleteMileage removes a signal from a track, returning domain.ErrNotFound if it isn't on the track.
+func (r *Repository) DeleteMileage(ctx context.Context, signalID, trackID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := mileageKey{signalID: signalID, trackID: trackID}
+	if _, ok := r.mileages[key]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityMileage, Key: fmt.Sprintf("of signal %d on track %d", signalID, trackID)}
+	}
+	delete(r.mileages, key)
+
+	return nil
+}
+
+// ListTrackMileages retrieves the mileages on a track ordered by mileage and then signal ID.
+// Handles paginated requests and returns the total count along with the returned mileages.
+func (r *Repository) ListTrackMileages(ctx context.Context, trackID, limit, page int) ([]domain.Mileage, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mileages := r.filterMileages(func(key mileageKey) bool { return key.trackID == trackID })
+	slices.SortFunc(mileages, func(a, b domain.Mileage) int {
+		return cmp.Or(cmp.Compare(a.Mileage, b.Mileage), cmp.Compare(a.SignalID, b.SignalID))
+	})
+
+	return paginate(mileages, limit, page), len(mileages), nil
+}
+
+// ListSignalMileages retrieves the mileages of a signal ordered by track ID.
+// Handles paginated requests and returns the total count along with the returned mileages.
+func (r *Repository) ListSignalMileages(ctx context.Context, signalID, limit, page int) ([]domain.Mileage, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mileages := r.filterMileages(func(key mileageKey) bool { return key.signalID == signalID })
+	slices.SortFunc(mileages, func(a, b domain.Mileage) int {
+		return cmp.Compare(a.TrackID, b.TrackID)
+	})
+
+	return paginate(mileages, limit, page), len(mileages), nil
+}
+
+// filterMileages returns the mileages whose key matches, the caller must hold the lock.
+func (r *Repository) filterMileages(match func(mileageKey) bool) []domain.Mileage {
+	mileages := []domain.Mileage{}
+	for key, m := range r.mileages {
+		if match(key) {
+			mileages = append(mileages, m)
+		}
+	}
+
+	return mileages
+}
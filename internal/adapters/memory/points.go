@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreatePoint inserts a new point, it fails when a point with the same ID exists.
+func (r *Repository) CreatePoint(ctx context.Context, point *domain.Point) error {
+	if err := checkPoint(point); err != nil {
+		return fmt.Errorf("inserting point: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.points[point.ID]; ok {
+		return &domain.ConflictError{Entity: domain.EntityPoint, Reason: fmt.Sprintf("point %d already exists", point.ID)}
+	}
+	if err := r.checkPointTracks(point); err != nil {
+		return err
+	}
+
+	stored := *point
+	if stored.Position == "" {
+		stored.Position = domain.PointNormal
+	}
+	r.points[point.ID] = stored
+
+	return nil
+}
+
+// GetPoint retrieves a point by its ID.
+func (r *Repository) GetPoint(ctx context.Context, pointID int) (*domain.Point, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	point, ok := r.points[pointID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityPoint, Key: strconv.Itoa(pointID)}
+	}
+
+	return &point, nil
+}
+
+// ListPoints retrieves the points at the location ordered by ID, every point when the location is empty.
+// Handles paginated requests and returns the total count along with the returned points.
+func (r *Repository) ListPoints(ctx context.Context, location string, limit, page int) ([]domain.Point, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	points := slices.DeleteFunc(sortedValues(r.points), func(point domain.Point) bool {
+		return location != "" && point.Location != location
+	})
+
+	return paginate(points, limit, page), len(points), nil
+}
+
+// UpdatePoint modifies an existing point.
+func (r *Repository) UpdatePoint(ctx context.Context, point *domain.Point) error {
+	if err := checkPoint(point); err != nil {
+		return fmt.Errorf("updating point: %w", err)
+	}
+	if point.Position == "" {
+		return fmt.Errorf("updating point: %w", domain.Invalid("position", "is required"))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.points[point.ID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityPoint, Key: strconv.Itoa(point.ID)}
+	}
+	if err := r.checkPointTracks(point); err != nil {
+		return err
+	}
+	r.points[point.ID] = *point
+
+	return nil
+}
+
+// DeletePoint removes a point, returning domain.ErrNotFound if it doesn't exist.
+func (r *Repository) DeletePoint(ctx context.Context, pointID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.points[pointID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityPoint, Key: strconv.Itoa(pointID)}
+	}
+	delete(r.points, pointID)
+
+	return nil
+}
+
+// checkPointTracks enforces the foreign keys of the points table, the caller must hold the lock.
+func (r *Repository) checkPointTracks(point *domain.Point) error {
+	for _, trackID := range []int{point.NormalTrackID, point.ReverseTrackID} {
+		if _, ok := r.tracks[trackID]; !ok {
+			return &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(trackID)}
+		}
+	}
+
+	return nil
+}
+
+// checkPoint enforces the same constraints as the points table.
+func checkPoint(point *domain.Point) error {
+	switch {
+	case point.ID == 0:
+		return domain.Invalid("id", "is required")
+	case point.Location == "":
+		return domain.Invalid("location", "is required")
+	case len(point.Location) > 255:
+		return domain.Invalid("location", "is longer than 255 characters")
+	case point.NormalTrackID == 0:
+		return domain.Invalid("normal_track_id", "is required")
+	case point.ReverseTrackID == 0:
+		return domain.Invalid("reverse_track_id", "is required")
+	case point.NormalTrackID == point.ReverseTrackID:
+		return domain.Invalid("reverse_track_id", "must not be the normal track")
+	}
+	if point.Position != "" {
+		if _, err := domain.ParsePointPosition(string(point.Position)); err != nil {
+			return domain.Invalid("position", err.Error())
+		}
+	}
+
+	return nil
+}
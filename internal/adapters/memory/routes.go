@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateRoute inserts a new route, it fails when a route with the same ID exists.
+func (r *Repository) CreateRoute(ctx context.Context, route *domain.Route) error {
+	if err := checkRoute(route); err != nil {
+		return fmt.Errorf("inserting route: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.routes[route.ID]; ok {
+		return &domain.ConflictError{Entity: domain.EntityRoute, Reason: fmt.Sprintf("route %d already exists", route.ID)}
+	}
+	for _, signalID := range []int{route.EntrySignalID, route.ExitSignalID} {
+		if _, ok := r.signals[signalID]; !ok {
+			return &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(signalID)}
+		}
+	}
+
+	stored := *route
+	stored.TrackIDs = slices.Clone(route.TrackIDs)
+	if stored.State == "" {
+		stored.State = domain.RouteReleased
+	}
+	r.routes[route.ID] = stored
+
+	return nil
+}
+
+// GetRoute retrieves a route by its ID.
+func (r *Repository) GetRoute(ctx context.Context, routeID int) (*domain.Route, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	route, ok := r.routes[routeID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityRoute, Key: strconv.Itoa(routeID)}
+	}
+	route.TrackIDs = slices.Clone(route.TrackIDs)
+
+	return &route, nil
+}
+
+// ListRoutes retrieves the routes in the state ordered by ID, every route when the state is empty.
+// Handles paginated requests and returns the total count along with the returned routes.
+func (r *Repository) ListRoutes(ctx context.Context, state domain.RouteState, limit, page int) ([]domain.Route, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	routes := slices.DeleteFunc(sortedValues(r.routes), func(route domain.Route) bool {
+		return state != "" && route.State != state
+	})
+	for i := range routes {
+		routes[i].TrackIDs = slices.Clone(routes[i].TrackIDs)
+	}
+
+	return paginate(routes, limit, page), len(routes), nil
+}
+
+// SetRouteState changes the state of an existing route.
+func (r *Repository) SetRouteState(ctx context.Context, routeID int, state domain.RouteState) error {
+	if _, err := domain.ParseRouteState(string(state)); err != nil {
+		return fmt.Errorf("updating route state: %w", domain.Invalid("state", err.Error()))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[routeID]
+	if !ok {
+		return &domain.NotFoundError{Entity: domain.EntityRoute, Key: strconv.Itoa(routeID)}
+	}
+	route.State = state
+	r.routes[routeID] = route
+
+	return nil
+}
+
+// DeleteRoute removes a route, returning domain.ErrNotFound if it doesn't exist.
+func (r *Repository) DeleteRoute(ctx context.Context, routeID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.routes[routeID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityRoute, Key: strconv.Itoa(routeID)}
+	}
+	delete(r.routes, routeID)
+
+	return nil
+}
+
+// LockRoutes does nothing, transactions on the repository are already serialized.
+func (r *Repository) LockRoutes(ctx context.Context) error {
+	return nil
+}
+
+// checkRoute enforces the same constraints as the routes table.
+func checkRoute(route *domain.Route) error {
+	switch {
+	case route.ID == 0:
+		return domain.Invalid("id", "is required")
+	case route.EntrySignalID == 0:
+		return domain.Invalid("entry_signal_id", "is required")
+	case route.ExitSignalID == 0:
+		return domain.Invalid("exit_signal_id", "is required")
+	case route.EntrySignalID == route.ExitSignalID:
+		return domain.Invalid("exit_signal_id", "must not be the entry signal")
+	case len(route.TrackIDs) == 0:
+		return domain.Invalid("track_ids", "is required")
+	}
+	if route.State != "" {
+		if _, err := domain.ParseRouteState(string(route.State)); err != nil {
+			return domain.Invalid("state", err.Error())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateSignal inserts a new signal.
+// When a signal with the same ID exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *Repository) CreateSignal(ctx context.Context, signal *domain.Signal, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	if err := checkSignal(signal); err != nil {
+		return "", fmt.Errorf("inserting signal: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.elrs[signal.ELR]; signal.ELR != "" && !ok {
+		return "", &domain.NotFoundError{Entity: domain.EntityELR, Key: signal.ELR}
+	}
+
+	existing, ok := r.signals[signal.ID]
+	if !ok {
+		r.signals[signal.ID] = *signal
+		return domain.WriteInserted, nil
+	}
+
+	outcome, err := policy.Resolve(existing == *signal)
+	if err != nil {
+		return "", &domain.ConflictError{Entity: domain.EntitySignal, Reason: fmt.Sprintf("signal %d already exists with a different name, ELR or kind", signal.ID)}
+	}
+	if outcome == domain.WriteUpdated {
+		r.signals[signal.ID] = *signal
+	}
+
+	return outcome, nil
+}
+
+// GetSignal retrieves a signal by its ID.
+func (r *Repository) GetSignal(ctx context.Context, signalID int) (*domain.Signal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	signal, ok := r.signals[signalID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(signalID)}
+	}
+
+	return &signal, nil
+}
+
+// ListSignals retrieves the signals matching the filter ordered by ID.
+// Handles paginated requests and returns the total count along with the returned signals.
+func (r *Repository) ListSignals(ctx context.Context, filter domain.SignalFilter, limit, page int) ([]domain.Signal, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	signals := slices.DeleteFunc(sortedValues(r.signals), func(signal domain.Signal) bool {
+		return !matchesSignal(filter, signal)
+	})
+
+	return paginate(signals, limit, page), len(signals), nil
+}
+
+// ListUnplacedSignals retrieves the signals that aren't on any track ordered by ID.
+// Handles paginated requests and returns the total count along with the returned signals.
+func (r *Repository) ListUnplacedSignals(ctx context.Context, limit, page int) ([]domain.Signal, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	unplaced := maps.Clone(r.signals)
+	for key := range r.mileages {
+		delete(unplaced, key.signalID)
+	}
+	signals := sortedValues(unplaced)
+
+	return paginate(signals, limit, page), len(signals), nil
+}
+
+// UpdateSignal modifies an existing signal.
+func (r *Repository) UpdateSignal(ctx context.Context, signal *domain.Signal) error {
+	if err := checkSignal(signal); err != nil {
+		return fmt.Errorf("updating signal: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.signals[signal.ID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(signal.ID)}
+	}
+	if _, ok := r.elrs[signal.ELR]; signal.ELR != "" && !ok {
+		return &domain.NotFoundError{Entity: domain.EntityELR, Key: signal.ELR}
+	}
+	r.signals[signal.ID] = *signal
+
+	return nil
+}
+
+// DeleteSignal removes a signal, it fails while the signal still has mileages on a track or begins or ends a route.
+// Returns domain.ErrNotFound if the signal doesn't exist.
+func (r *Repository) DeleteSignal(ctx context.Context, signalID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.signals[signalID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(signalID)}
+	}
+	for key := range r.mileages {
+		if key.signalID == signalID {
+			return &domain.ConflictError{Entity: domain.EntitySignal, Reason: fmt.Sprintf("signal %d is still on a track", signalID)}
+		}
+	}
+	for _, route := range r.routes {
+		if route.EntrySignalID == signalID || route.ExitSignalID == signalID {
+			return &domain.ConflictError{Entity: domain.EntitySignal, Reason: fmt.Sprintf("signal %d is still referenced by routes", signalID)}
+		}
+	}
+
+	delete(r.signals, signalID)
+
+	return nil
+}
+
+// matchesSignal reports whether the signal has every attribute set in the filter.
+func matchesSignal(filter domain.SignalFilter, signal domain.Signal) bool {
+	switch {
+	case filter.Type != "" && filter.Type != signal.Type:
+		return false
+	case filter.Aspects != 0 && filter.Aspects != signal.Aspects:
+		return false
+	case filter.RouteIndicator != "" && filter.RouteIndicator != signal.RouteIndicator:
+		return false
+	case filter.Automatic != nil && *filter.Automatic != signal.Automatic:
+		return false
+	}
+
+	return true
+}
+
+// checkSignal enforces the same constraints as the signals table.
+func checkSignal(signal *domain.Signal) error {
+	switch {
+	case signal.ID == 0:
+		return domain.Invalid("id", "is required")
+	case len(signal.ELR) > 4:
+		return domain.Invalid("elr", "is longer than 4 characters")
+	case len(signal.Name) > 255:
+		return domain.Invalid("signal_name", "is longer than 255 characters")
+	case signal.Aspects != 0 && (signal.Aspects < 2 || signal.Aspects > 4):
+		return domain.Invalid("aspects", "must be between 2 and 4")
+	}
+	if signal.Type != "" {
+		if _, err := domain.ParseSignalType(string(signal.Type)); err != nil {
+			return domain.Invalid("type", err.Error())
+		}
+	}
+	if signal.RouteIndicator != "" {
+		if _, err := domain.ParseRouteIndicator(string(signal.RouteIndicator)); err != nil {
+			return domain.Invalid("route_indicator", err.Error())
+		}
+	}
+
+	return nil
+}
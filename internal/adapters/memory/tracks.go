@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateTrack inserts a new track.
+// When a track with the same ID exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *Repository) CreateTrack(ctx context.Context, track *domain.Track, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	if err := checkTrack(track); err != nil {
+		return "", fmt.Errorf("inserting track: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tracks[track.ID]
+	if !ok {
+		r.tracks[track.ID] = *track
+		return domain.WriteInserted, nil
+	}
+
+	outcome, err := policy.Resolve(existing == *track)
+	if err != nil {
+		return "", &domain.ConflictError{Entity: domain.EntityTrack, Reason: fmt.Sprintf("track %d already exists with a different source or target", track.ID)}
+	}
+	if outcome == domain.WriteUpdated {
+		r.tracks[track.ID] = *track
+	}
+
+	return outcome, nil
+}
+
+// GetTrack retrieves a track by its ID.
+func (r *Repository) GetTrack(ctx context.Context, trackID int) (*domain.Track, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	track, ok := r.tracks[trackID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(trackID)}
+	}
+
+	return &track, nil
+}
+
+// ListTracks retrieves all tracks ordered by ID.
+// Handles paginated requests and returns the total count along with the returned tracks.
+func (r *Repository) ListTracks(ctx context.Context, limit, page int) ([]domain.Track, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tracks := sortedValues(r.tracks)
+
+	return paginate(tracks, limit, page), len(tracks), nil
+}
+
+// UpdateTrack modifies an existing track.
+func (r *Repository) UpdateTrack(ctx context.Context, track *domain.Track) error {
+	if err := checkTrack(track); err != nil {
+		return fmt.Errorf("updating track: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tracks[track.ID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(track.ID)}
+	}
+	r.tracks[track.ID] = *track
+
+	return nil
+}
+
+// DeleteTrack removes a track along with its mileages and the points that switch onto it.
+// Returns domain.ErrNotFound if the track doesn't exist.
+func (r *Repository) DeleteTrack(ctx context.Context, trackID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tracks[trackID]; !ok {
+		return &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(trackID)}
+	}
+	delete(r.tracks, trackID)
+	for key := range r.mileages {
+		if key.trackID == trackID {
+			delete(r.mileages, key)
+		}
+	}
+	for id, point := range r.points {
+		if point.NormalTrackID == trackID || point.ReverseTrackID == trackID {
+			delete(r.points, id)
+		}
+	}
+
+	return nil
+}
+
+// ListSignalTracks retrieves all tracks associated with the given signal ordered by ID.
+// Handles paginated requests and returns the total count along with the returned tracks.
+func (r *Repository) ListSignalTracks(ctx context.Context, signalID, limit, page int) ([]domain.Track, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	signalTracks := make(map[int]domain.Track)
+	for key := range r.mileages {
+		if key.signalID == signalID {
+			signalTracks[key.trackID] = r.tracks[key.trackID]
+		}
+	}
+	tracks := sortedValues(signalTracks)
+
+	return paginate(tracks, limit, page), len(tracks), nil
+}
+
+// checkTrack enforces the same constraints as the tracks table.
+func checkTrack(track *domain.Track) error {
+	switch {
+	case track.ID == 0:
+		return domain.Invalid("id", "is required")
+	case track.Source == "":
+		return domain.Invalid("source", "is required")
+	case track.Target == "":
+		return domain.Invalid("target", "is required")
+	case len(track.Source) > 255:
+		return domain.Invalid("source", "is longer than 255 characters")
+	case len(track.Target) > 255:
+		return domain.Invalid("target", "is longer than 255 characters")
+	}
+
+	return nil
+}
+
+// ListTrackSignals retrieves tracks ordered by ID along with their signals ordered by mileage.
+// Handles paginated requests and returns the total count along with the returned tracks.
+func (r *Repository) ListTrackSignals(ctx context.Context, limit, page int) ([]domain.TrackSignals, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tracks := sortedValues(r.tracks)
+
+	trackSignals := make([]domain.TrackSignals, 0, len(tracks))
+	for _, t := range paginate(tracks, limit, page) {
+		trackSignals = append(trackSignals, domain.TrackSignals{
+			ID:      t.ID,
+			Source:  t.Source,
+			Target:  t.Target,
+			Signals: r.trackSignals(t.ID),
+		})
+	}
+
+	return trackSignals, len(tracks), nil
+}
+
+// GetTrackSignals retrieves a track by its ID along with its signals ordered by mileage.
+func (r *Repository) GetTrackSignals(ctx context.Context, trackID int) (*domain.TrackSignals, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	track, ok := r.tracks[trackID]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(trackID)}
+	}
+
+	return &domain.TrackSignals{
+		ID:      track.ID,
+		Source:  track.Source,
+		Target:  track.Target,
+		Signals: r.trackSignals(trackID),
+	}, nil
+}
+
+// trackSignals returns the signals on the track ordered by mileage, the caller must hold the lock.
+func (r *Repository) trackSignals(trackID int) []domain.TrackSignal {
+	var signals []domain.TrackSignal
+	for key, m := range r.mileages {
+		if key.trackID != trackID {
+			continue
+		}
+		s := r.signals[key.signalID]
+		signals = append(signals, domain.TrackSignal{
+			ID:         s.ID,
+			Name:       s.Name,
+			ELR:        s.ELR,
+			Mileage:    &m.Mileage,
+			SignalKind: s.SignalKind,
+		})
+	}
+
+	sort.Slice(signals, func(i, j int) bool {
+		if *signals[i].Mileage != *signals[j].Mileage {
+			return *signals[i].Mileage < *signals[j].Mileage
+		}
+		return signals[i].ID < signals[j].ID
+	})
+
+	return signals
+}
@@ -0,0 +1,18 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/adapters/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		_, err := underlyingDB.Exec("TRUNCATE points, routes, mileages, tracks, signals, elrs")
+		require.NoError(t, err, "truncating tables")
+
+		return storetest.Stores{Signals: testDB, Tracks: testDB, Mileages: testDB, ELRs: testDB, Routes: testDB, Points: testDB, Transactor: testDB}
+	})
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateELR inserts a new ELR into the database.
+// When an ELR with the same code exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *PostgresRepository) CreateELR(ctx context.Context, elr *domain.ELR, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	outcome := domain.WriteInserted
+	err := r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, elr).Table("elrs").OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("inserting elr into store")
+			return fmt.Errorf("inserting elr: %w", mapError(err, domain.EntityELR, elr.Code))
+		}
+		if res.RowsAffected() == 1 {
+			return nil
+		}
+
+		existing := &domain.ELR{}
+		err = tx.ModelContext(ctx, existing).Table("elrs").
+			Where("code = ?", elr.Code).
+			For("UPDATE").
+			Select()
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("getting existing elr from store")
+			return fmt.Errorf("getting existing elr: %w", err)
+		}
+
+		outcome, err = policy.Resolve(*existing == *elr)
+		if err != nil {
+			return &domain.ConflictError{Entity: domain.EntityELR, Reason: fmt.Sprintf("elr %s already exists with different details", elr.Code)}
+		}
+		if outcome != domain.WriteUpdated {
+			return nil
+		}
+
+		if _, err := tx.ModelContext(ctx, elr).Table("elrs").Where("code = ?code").Update(); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("overwriting elr")
+			return fmt.Errorf("overwriting elr: %w", mapError(err, domain.EntityELR, elr.Code))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return outcome, nil
+}
+
+// GetELR retrieves an ELR by its code.
+func (r *PostgresRepository) GetELR(ctx context.Context, code string) (*domain.ELR, error) {
+	elr := &domain.ELR{}
+	err := r.conn().ModelContext(ctx, elr).Table("elrs").Where("code = ?", code).Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntityELR, code)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("getting elr from store")
+		return nil, fmt.Errorf("getting elr: %w", err)
+	}
+
+	return elr, nil
+}
+
+// ListELRs retrieves all ELRs ordered by code.
+// Handles paginated requests and returns the total count along with the returned ELRs.
+func (r *PostgresRepository) ListELRs(ctx context.Context, limit, page int) ([]domain.ELR, int, error) {
+	elrs := []domain.ELR{}
+	count, err := r.conn().ModelContext(ctx, &elrs).Table("elrs").
+		Order("code ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing elrs from store")
+		return nil, 0, fmt.Errorf("listing elrs: %w", err)
+	}
+
+	return elrs, count, nil
+}
+
+// UpdateELR modifies an existing ELR.
+func (r *PostgresRepository) UpdateELR(ctx context.Context, elr *domain.ELR) error {
+	return r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, elr).Table("elrs").Where("code = ?code").Update()
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("updating elr")
+			return fmt.Errorf("updating elr: %w", mapError(err, domain.EntityELR, elr.Code))
+		}
+		if res.RowsAffected() == 0 {
+			return &domain.NotFoundError{Entity: domain.EntityELR, Key: elr.Code}
+		}
+
+		return nil
+	})
+}
+
+// DeleteELR removes an ELR from the database, it fails while signals still reference it.
+// Returns domain.ErrNotFound if the ELR doesn't exist.
+func (r *PostgresRepository) DeleteELR(ctx context.Context, code string) error {
+	res, err := r.conn().ModelContext(ctx, &domain.ELR{}).Table("elrs").Where("code = ?", code).Delete()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("deleting elr")
+		return fmt.Errorf("deleting elr: %w", mapError(err, domain.EntityELR, code))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityELR, Key: code}
+	}
+
+	return nil
+}
+
+// ListELRSignals retrieves the signals on the ELR that are on a track, ordered by mileage, track and signal ID.
+// Handles paginated requests and returns the total count along with the returned signals.
+func (r *PostgresRepository) ListELRSignals(ctx context.Context, code string, limit, page int) ([]domain.ELRSignal, int, error) {
+	var count int
+	_, err := r.conn().QueryOneContext(ctx, pg.Scan(&count), `
+		SELECT count(*)
+		FROM mileages AS m
+		JOIN signals AS s ON s.id = m.signal_id
+		WHERE s.elr = ?`, code)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("counting elr signals in store")
+		return nil, 0, fmt.Errorf("counting elr signals: %w", err)
+	}
+
+	signals := []domain.ELRSignal{}
+	// A zero limit returns every signal, LIMIT NULL is the same as no limit.
+	_, err = r.conn().QueryContext(ctx, &signals, `
+		SELECT m.track_id, s.id, s.name, s.elr, m.mileage, s.type, s.aspects, s.route_indicator, s.automatic
+		FROM mileages AS m
+		JOIN signals AS s ON s.id = m.signal_id
+		WHERE s.elr = ?
+		ORDER BY m.mileage, m.track_id, s.id
+		LIMIT NULLIF(?, 0) OFFSET ?`, code, limit, page*limit)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing elr signals from store")
+		return nil, 0, fmt.Errorf("listing elr signals: %w", err)
+	}
+
+	return signals, count, nil
+}
+
+// ListSignalsNear retrieves the signals on the ELR from the mileage onwards in the direction, nearest first.
+// Ties are ordered by track and signal ID. The range is read through the index on mileages.
+func (r *PostgresRepository) ListSignalsNear(ctx context.Context, code string, mileage domain.Distance, direction domain.Direction, limit int) ([]domain.NearbySignal, error) {
+	// Mileages increase in the down direction.
+	distance, within, order := "m.mileage - ?0", "m.mileage >= ?0", "m.mileage"
+	if direction == domain.DirectionUp {
+		distance, within, order = "?0 - m.mileage", "m.mileage <= ?0", "m.mileage DESC"
+	}
+
+	signals := []domain.NearbySignal{}
+	// A zero limit returns every signal, LIMIT NULL is the same as no limit.
+	_, err := r.conn().QueryContext(ctx, &signals, fmt.Sprintf(`
+		SELECT m.track_id, s.id, s.name, s.elr, m.mileage, s.type, s.aspects, s.route_indicator, s.automatic, %s AS distance, ?1 AS direction
+		FROM mileages AS m
+		JOIN signals AS s ON s.id = m.signal_id
+		WHERE s.elr = ?2 AND %s
+		ORDER BY %s, m.track_id, s.id
+		LIMIT NULLIF(?3, 0)`, distance, within, order), mileage, direction, code, limit)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing signals near mileage from store")
+		return nil, fmt.Errorf("listing signals near mileage: %w", err)
+	}
+
+	return signals, nil
+}
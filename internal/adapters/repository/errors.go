@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// PostgreSQL error codes that are mapped to domain errors.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	notNullViolation    = "23502"
+	checkViolation      = "23514"
+	stringTooLong       = "22001"
+)
+
+// violatedKey matches the key in the detail of a constraint violation, for example Key (signal_id)=(404).
+var violatedKey = regexp.MustCompile(`Key \((\w+)\)=\(([^)]*)\)`)
+
+// mapError converts go-pg and PostgreSQL errors into domain errors, other errors are returned as they are.
+// entity and key describe the entity that was being read or written.
+func mapError(err error, entity domain.Entity, key string) error {
+	if errors.Is(err, pg.ErrNoRows) {
+		return &domain.NotFoundError{Entity: entity, Key: key}
+	}
+
+	var pgErr pg.Error
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Field('C') {
+	case uniqueViolation:
+		return &domain.ConflictError{Entity: entity, Reason: fmt.Sprintf("%s %s already exists", entity, key)}
+	case foreignKeyViolation:
+		detail := pgErr.Field('D')
+		if match := violatedKey.FindStringSubmatch(detail); match != nil && strings.Contains(detail, "is not present") {
+			// Writing a reference to an entity that doesn't exist.
+			return &domain.NotFoundError{Entity: referencedEntity(match[1]), Key: match[2]}
+		}
+		// Deleting an entity that is still referenced.
+		return &domain.ConflictError{Entity: entity, Reason: fmt.Sprintf("%s %s is still referenced by %s", entity, key, pgErr.Field('t'))}
+	case notNullViolation:
+		return domain.Invalid(pgErr.Field('c'), "is required")
+	case checkViolation, stringTooLong:
+		field := pgErr.Field('c')
+		if field == "" {
+			field = string(entity)
+		}
+		return domain.Invalid(field, pgErr.Field('M'))
+	}
+
+	return err
+}
+
+// referencedEntity returns the entity referenced by a foreign key column.
+func referencedEntity(column string) domain.Entity {
+	switch column {
+	case "signal_id", "entry_signal_id", "exit_signal_id":
+		return domain.EntitySignal
+	case "track_id", "normal_track_id", "reverse_track_id":
+		return domain.EntityTrack
+	case "elr":
+		return domain.EntityELR
+	}
+
+	return domain.Entity(strings.TrimSuffix(column, "_id"))
+}
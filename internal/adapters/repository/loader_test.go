@@ -0,0 +1,51 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// A record rejected part way through a load must not abort the Postgres transaction,
+// otherwise every later write fails and the load reports a storage error instead of the issues.
+func TestLoadTrackSignalsUnregisteredELR(t *testing.T) {
+	ctx := context.Background()
+	_, err := underlyingDB.Exec("TRUNCATE points, routes, mileages, tracks, signals, elrs")
+	require.NoError(t, err, "truncating tables")
+	_, err = testDB.CreateELR(ctx, &domain.ELR{Code: "ABC"}, domain.ConflictFail)
+	require.NoError(t, err, "registering ELR")
+
+	s := &application.Service{
+		Logger:       logrus.New(),
+		SignalStore:  testDB,
+		TrackStore:   testDB,
+		MileageStore: testDB,
+		ELRStore:     testDB,
+		RouteStore:   testDB,
+		PointStore:   testDB,
+		Transactor:   testDB,
+	}
+
+	mileage := domain.Miles(1)
+	err = s.LoadTrackSignals(ctx, application.SliceSource(domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: &mileage}}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 2, Name: "SIG2", ELR: "ZZZ", Mileage: &mileage}}},
+		{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: &mileage}}},
+	}), application.DefaultLoadPolicies, nil)
+
+	var validationErr *application.LoadValidationError
+	require.ErrorAs(t, err, &validationErr, "loading track signals")
+	assert.Equal(t, []domain.LoadIssue{
+		{Index: 1, TrackID: 2, SignalID: 2, Field: "signal_ids[0].elr", Reason: "is not registered"},
+	}, validationErr.Issues, "load issues")
+
+	tracks, _, err := testDB.ListTracks(ctx, 0, 0)
+	require.NoError(t, err, "listing tracks")
+	assert.Empty(t, tracks, "tracks after the load is rolled back")
+}
@@ -0,0 +1,3 @@
+ALTER TABLE signals DROP CONSTRAINT signals_elr_fkey;
+DROP INDEX signals_elr_idx;
+DROP TABLE elrs;
@@ -0,0 +1,14 @@
+CREATE TABLE elrs (
+    code VARCHAR(4) PRIMARY KEY,
+    description VARCHAR(255),
+    start_mileage REAL,
+    end_mileage REAL,
+    start_location VARCHAR(255),
+    end_location VARCHAR(255)
+);
+
+INSERT INTO elrs (code) SELECT DISTINCT elr FROM signals WHERE elr IS NOT NULL;
+
+ALTER TABLE signals ADD CONSTRAINT signals_elr_fkey FOREIGN KEY (elr) REFERENCES elrs (code);
+
+CREATE INDEX signals_elr_idx ON signals (elr);
@@ -0,0 +1,3 @@
+ALTER TABLE mileages ALTER COLUMN mileage TYPE REAL USING mileage / 1760.0;
+ALTER TABLE elrs ALTER COLUMN start_mileage TYPE REAL USING start_mileage / 1760.0;
+ALTER TABLE elrs ALTER COLUMN end_mileage TYPE REAL USING end_mileage / 1760.0;
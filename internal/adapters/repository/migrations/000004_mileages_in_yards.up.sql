@@ -0,0 +1,4 @@
+-- Mileages are held exactly as whole yards rather than as approximate decimal miles.
+ALTER TABLE mileages ALTER COLUMN mileage TYPE BIGINT USING round(mileage * 1760);
+ALTER TABLE elrs ALTER COLUMN start_mileage TYPE BIGINT USING round(start_mileage * 1760);
+ALTER TABLE elrs ALTER COLUMN end_mileage TYPE BIGINT USING round(end_mileage * 1760);
@@ -0,0 +1 @@
+DROP INDEX mileages_mileage_idx;
@@ -0,0 +1,2 @@
+-- Supports looking up the signals either side of a mileage.
+CREATE INDEX mileages_mileage_idx ON mileages (mileage, signal_id);
@@ -0,0 +1,6 @@
+DROP INDEX signals_type_idx;
+ALTER TABLE signals
+    DROP COLUMN type,
+    DROP COLUMN aspects,
+    DROP COLUMN route_indicator,
+    DROP COLUMN automatic;
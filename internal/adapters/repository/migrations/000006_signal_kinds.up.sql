@@ -0,0 +1,9 @@
+-- Existing signals are left unclassified.
+ALTER TABLE signals
+    ADD COLUMN type VARCHAR(32) CHECK (type IN ('main', 'distant', 'shunt', 'banner_repeater', 'stop_board')),
+    ADD COLUMN aspects SMALLINT CHECK (aspects BETWEEN 2 AND 4),
+    ADD COLUMN route_indicator VARCHAR(32) CHECK (route_indicator IN ('junction', 'theatre')),
+    ADD COLUMN automatic BOOLEAN NOT NULL DEFAULT false;
+
+-- Supports filtering signals by what they are.
+CREATE INDEX signals_type_idx ON signals (type, aspects);
@@ -0,0 +1,2 @@
+DROP INDEX routes_state_idx;
+DROP TABLE routes;
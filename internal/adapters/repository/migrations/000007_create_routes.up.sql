@@ -0,0 +1,13 @@
+-- Track IDs are kept in order of travel, they aren't constrained because arrays can't hold foreign keys.
+CREATE TABLE routes (
+    id INTEGER PRIMARY KEY,
+    entry_signal_id INTEGER NOT NULL REFERENCES signals (id),
+    exit_signal_id INTEGER NOT NULL REFERENCES signals (id),
+    track_ids INTEGER[] NOT NULL,
+    state VARCHAR(16) NOT NULL DEFAULT 'released' CHECK (state IN ('released', 'set')),
+    CHECK (entry_signal_id <> exit_signal_id),
+    CHECK (cardinality(track_ids) > 0)
+);
+
+-- Supports finding the routes that are set.
+CREATE INDEX routes_state_idx ON routes (state);
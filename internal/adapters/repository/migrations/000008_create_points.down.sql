@@ -0,0 +1,2 @@
+DROP INDEX points_location_idx;
+DROP TABLE points;
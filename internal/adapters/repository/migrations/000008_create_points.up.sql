@@ -0,0 +1,12 @@
+-- A point goes with the tracks it switches between.
+CREATE TABLE points (
+    id INTEGER PRIMARY KEY,
+    location VARCHAR(255) NOT NULL,
+    normal_track_id INTEGER NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+    reverse_track_id INTEGER NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+    position VARCHAR(16) NOT NULL DEFAULT 'normal' CHECK (position IN ('normal', 'reverse')),
+    CHECK (normal_track_id <> reverse_track_id)
+);
+
+-- Supports finding the points at a location when walking the network.
+CREATE INDEX points_location_idx ON points (location);
@@ -2,21 +2,133 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
-// AddMileage addes a mileage value linking a signal with a track.
-func (r *PostgresRepository) AddMileage(ctx context.Context, mileage *domain.Mileage) error {
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := r.db.Model(mileage).Table("mileages").Insert()
+// AddMileage adds a mileage value linking a signal with a track.
+// When the signal already has a mileage on the track the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *PostgresRepository) AddMileage(ctx context.Context, mileage *domain.Mileage, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	outcome := domain.WriteInserted
+	err := r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		existing := &domain.Mileage{}
+		err := tx.ModelContext(ctx, existing).Table("mileages").
+			Where("signal_id = ? AND track_id = ?", mileage.SignalID, mileage.TrackID).
+			For("UPDATE").
+			Select()
+		if errors.Is(err, pg.ErrNoRows) {
+			if _, err := tx.ModelContext(ctx, mileage).Table("mileages").Insert(); err != nil {
+				r.logger.WithContext(ctx).WithError(err).Error("inserting signal mileage into store")
+				return fmt.Errorf("inserting signal mileage: %w", mapError(err, domain.EntityMileage, mileageKey(mileage.SignalID, mileage.TrackID)))
+			}
+			return nil
+		}
 		if err != nil {
-			r.logger.WithContext(ctx).WithError(err).Error("inserting signal mileage into store")
-			return fmt.Errorf("inserting signal mileage: %w", err)
+			r.logger.WithContext(ctx).WithError(err).Error("getting existing signal mileage from store")
+			return fmt.Errorf("getting existing signal mileage: %w", err)
+		}
+
+		outcome, err = policy.Resolve(*existing == *mileage)
+		if err != nil {
+			return &domain.ConflictError{Entity: domain.EntityMileage, Reason: fmt.Sprintf("signal %d already has a different mileage on track %d", mileage.SignalID, mileage.TrackID)}
+		}
+		if outcome != domain.WriteUpdated {
+			return nil
+		}
+
+		_, err = tx.ModelContext(ctx, mileage).Table("mileages").
+			Column("mileage").
+			Where("signal_id = ? AND track_id = ?", mileage.SignalID, mileage.TrackID).
+			Update()
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("overwriting signal mileage")
+			return fmt.Errorf("overwriting signal mileage: %w", mapError(err, domain.EntityMileage, mileageKey(mileage.SignalID, mileage.TrackID)))
 		}
 
 		return nil
 	})
+	if err != nil {
+		return "", err
+	}
+
+	return outcome, nil
+}
+
+// GetMileage retrieves the mileage of a signal on a track.
+func (r *PostgresRepository) GetMileage(ctx context.Context, signalID, trackID int) (*domain.Mileage, error) {
+	mileage := &domain.Mileage{}
+	err := r.conn().ModelContext(ctx, mileage).Table("mileages").
+		Where("signal_id = ? AND track_id = ?", signalID, trackID).
+		Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntityMileage, mileageKey(signalID, trackID))
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("getting signal mileage from store")
+		return nil, fmt.Errorf("getting signal mileage: %w", err)
+	}
+
+	return mileage, nil
+}
+
+// DeleteMileage removes a signal from a track, returning domain.ErrNotFound if it isn't on the track.
+func (r *PostgresRepository) DeleteMileage(ctx context.Context, signalID, trackID int) error {
+	res, err := r.conn().ModelContext(ctx, &domain.Mileage{}).Table("mileages").
+		Where("signal_id = ? AND track_id = ?", signalID, trackID).
+		Delete()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("deleting signal mileage")
+		return fmt.Errorf("deleting signal mileage: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityMileage, Key: mileageKey(signalID, trackID)}
+	}
+
+	return nil
+}
+
+// ListTrackMileages retrieves the mileages on a track ordered by mileage and then signal ID.
+// Handles paginated requests and returns the total count along with the returned mileages.
+func (r *PostgresRepository) ListTrackMileages(ctx context.Context, trackID, limit, page int) ([]domain.Mileage, int, error) {
+	mileages := []domain.Mileage{}
+
+	count, err := r.conn().ModelContext(ctx, &mileages).Table("mileages").
+		Where("track_id = ?", trackID).
+		Order("mileage ASC", "signal_id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing track mileages from store")
+		return nil, 0, fmt.Errorf("listing track mileages: %w", err)
+	}
+
+	return mileages, count, nil
+}
+
+// ListSignalMileages retrieves the mileages of a signal ordered by track ID.
+// Handles paginated requests and returns the total count along with the returned mileages.
+func (r *PostgresRepository) ListSignalMileages(ctx context.Context, signalID, limit, page int) ([]domain.Mileage, int, error) {
+	mileages := []domain.Mileage{}
+
+	count, err := r.conn().ModelContext(ctx, &mileages).Table("mileages").
+		Where("signal_id = ?", signalID).
+		Order("track_id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing signal mileages from store")
+		return nil, 0, fmt.Errorf("listing signal mileages: %w", err)
+	}
+
+	return mileages, count, nil
+}
+
+// mileageKey describes the mileage of a signal on a track in errors.
+func mileageKey(signalID, trackID int) string {
+	return fmt.Sprintf("of signal %d on track %d", signalID, trackID)
 }
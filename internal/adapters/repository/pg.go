@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-pg/migrations/v8"
 	"github.com/go-pg/pg/v10"
 	"github.com/sirupsen/logrus"
+	"github.com/warrenb95/railway-signals/internal/domain"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq" // Required for PostgreSQL
@@ -13,7 +15,9 @@ import (
 
 // PostgresRepository struct for database interactions
 type PostgresRepository struct {
-	db     *pg.DB
+	db *pg.DB
+	// tx is set when the repository is a transactional view handed out by InTransaction.
+	tx     *pg.Tx
 	logger *logrus.Logger
 }
 
@@ -23,6 +27,50 @@ func NewPostgresRepository(db *pg.DB, logger *logrus.Logger) (*PostgresRepositor
 	return p, p.runMigrations()
 }
 
+// InTransaction calls fn with a view of the repository bound to a single database transaction.
+// The transaction commits when fn returns nil and rolls back otherwise.
+func (r *PostgresRepository) InTransaction(ctx context.Context, fn func(ctx context.Context, tx domain.Tx) error) error {
+	if r.tx != nil {
+		return fn(ctx, r)
+	}
+
+	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		return fn(ctx, &PostgresRepository{db: r.db, tx: tx, logger: r.logger})
+	})
+}
+
+// InSnapshot calls fn with a view of the repository bound to a read-only repeatable read transaction,
+// every query made through it sees the database as it was at the first one.
+func (r *PostgresRepository) InSnapshot(ctx context.Context, fn func(ctx context.Context, tx domain.Tx) error) error {
+	if r.tx != nil {
+		return fn(ctx, r)
+	}
+
+	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		if _, err := tx.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY"); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("starting snapshot")
+			return fmt.Errorf("starting snapshot: %w", err)
+		}
+		return fn(ctx, &PostgresRepository{db: r.db, tx: tx, logger: r.logger})
+	})
+}
+
+// conn returns the transaction the repository is bound to, or the database otherwise.
+func (r *PostgresRepository) conn() pg.DBI {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// runInTransaction runs fn in the repository's transaction, or in a new one when it is not bound to one.
+func (r *PostgresRepository) runInTransaction(ctx context.Context, fn func(tx *pg.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.db.RunInTransaction(ctx, fn)
+}
+
 func (r *PostgresRepository) runMigrations() error {
 	// run migrations
 	collection := migrations.NewCollection()
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreatePoint inserts a new point into the database, it fails when a point with the same ID exists.
+func (r *PostgresRepository) CreatePoint(ctx context.Context, point *domain.Point) error {
+	if _, err := r.conn().ModelContext(ctx, point).Insert(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("inserting point into store")
+		return fmt.Errorf("inserting point: %w", mapError(err, domain.EntityPoint, strconv.Itoa(point.ID)))
+	}
+
+	return nil
+}
+
+// GetPoint retrieves a point by its ID.
+func (r *PostgresRepository) GetPoint(ctx context.Context, pointID int) (*domain.Point, error) {
+	point := &domain.Point{ID: pointID}
+	err := r.conn().ModelContext(ctx, point).WherePK().Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntityPoint, strconv.Itoa(pointID))
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("getting point from store")
+		return nil, fmt.Errorf("getting point: %w", err)
+	}
+
+	return point, nil
+}
+
+// ListPoints retrieves the points at the location ordered by ID, every point when the location is empty.
+// Handles paginated requests and returns the total count along with the returned points.
+func (r *PostgresRepository) ListPoints(ctx context.Context, location string, limit, page int) ([]domain.Point, int, error) {
+	points := []domain.Point{}
+
+	query := r.conn().ModelContext(ctx, &points)
+	if location != "" {
+		query = query.Where("location = ?", location)
+	}
+
+	count, err := query.
+		Order("id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing points from store")
+		return nil, 0, fmt.Errorf("listing points: %w", err)
+	}
+
+	return points, count, nil
+}
+
+// UpdatePoint modifies an existing point.
+func (r *PostgresRepository) UpdatePoint(ctx context.Context, point *domain.Point) error {
+	res, err := r.conn().ModelContext(ctx, point).WherePK().Update()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("updating point")
+		return fmt.Errorf("updating point: %w", mapError(err, domain.EntityPoint, strconv.Itoa(point.ID)))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityPoint, Key: strconv.Itoa(point.ID)}
+	}
+
+	return nil
+}
+
+// DeletePoint removes a point from the database, returning domain.ErrNotFound if it doesn't exist.
+func (r *PostgresRepository) DeletePoint(ctx context.Context, pointID int) error {
+	res, err := r.conn().ModelContext(ctx, &domain.Point{ID: pointID}).WherePK().Delete()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("deleting point")
+		return fmt.Errorf("deleting point: %w", mapError(err, domain.EntityPoint, strconv.Itoa(pointID)))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityPoint, Key: strconv.Itoa(pointID)}
+	}
+
+	return nil
+}
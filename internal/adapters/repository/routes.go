@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateRoute inserts a new route into the database, it fails when a route with the same ID exists.
+func (r *PostgresRepository) CreateRoute(ctx context.Context, route *domain.Route) error {
+	if _, err := r.conn().ModelContext(ctx, route).Insert(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("inserting route into store")
+		return fmt.Errorf("inserting route: %w", mapError(err, domain.EntityRoute, strconv.Itoa(route.ID)))
+	}
+
+	return nil
+}
+
+// GetRoute retrieves a route by its ID.
+func (r *PostgresRepository) GetRoute(ctx context.Context, routeID int) (*domain.Route, error) {
+	route := &domain.Route{ID: routeID}
+	err := r.conn().ModelContext(ctx, route).WherePK().Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntityRoute, strconv.Itoa(routeID))
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("getting route from store")
+		return nil, fmt.Errorf("getting route: %w", err)
+	}
+
+	return route, nil
+}
+
+// ListRoutes retrieves the routes in the state ordered by ID, every route when the state is empty.
+// Handles paginated requests and returns the total count along with the returned routes.
+func (r *PostgresRepository) ListRoutes(ctx context.Context, state domain.RouteState, limit, page int) ([]domain.Route, int, error) {
+	routes := []domain.Route{}
+
+	query := r.conn().ModelContext(ctx, &routes)
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	count, err := query.
+		Order("id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing routes from store")
+		return nil, 0, fmt.Errorf("listing routes: %w", err)
+	}
+
+	return routes, count, nil
+}
+
+// SetRouteState changes the state of an existing route.
+func (r *PostgresRepository) SetRouteState(ctx context.Context, routeID int, state domain.RouteState) error {
+	route := &domain.Route{ID: routeID, State: state}
+	res, err := r.conn().ModelContext(ctx, route).Column("state").WherePK().Update()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("updating route state")
+		return fmt.Errorf("updating route state: %w", mapError(err, domain.EntityRoute, strconv.Itoa(routeID)))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityRoute, Key: strconv.Itoa(routeID)}
+	}
+
+	return nil
+}
+
+// DeleteRoute removes a route from the database, returning domain.ErrNotFound if it doesn't exist.
+func (r *PostgresRepository) DeleteRoute(ctx context.Context, routeID int) error {
+	res, err := r.conn().ModelContext(ctx, &domain.Route{ID: routeID}).WherePK().Delete()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("deleting route")
+		return fmt.Errorf("deleting route: %w", mapError(err, domain.EntityRoute, strconv.Itoa(routeID)))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityRoute, Key: strconv.Itoa(routeID)}
+	}
+
+	return nil
+}
+
+// LockRoutes takes a lock on the routes table that conflicts with itself and with writes,
+// so transactions setting routes run one after another. Outside a transaction it does nothing.
+func (r *PostgresRepository) LockRoutes(ctx context.Context) error {
+	if r.tx == nil {
+		return nil
+	}
+
+	if _, err := r.tx.ExecContext(ctx, "LOCK TABLE routes IN EXCLUSIVE MODE"); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("locking routes")
+		return fmt.Errorf("locking routes: %w", err)
+	}
+
+	return nil
+}
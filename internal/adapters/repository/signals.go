@@ -4,28 +4,61 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
 // CreateSignal inserts a new signal into the database.
-func (r *PostgresRepository) CreateSignal(ctx context.Context, signal *domain.Signal) error {
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := r.db.Model(signal).OnConflict("DO NOTHING").Insert()
+// When a signal with the same ID exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *PostgresRepository) CreateSignal(ctx context.Context, signal *domain.Signal, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	outcome := domain.WriteInserted
+	err := r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, signal).OnConflict("DO NOTHING").Insert()
 		if err != nil {
 			r.logger.WithContext(ctx).WithError(err).Error("inserting signal into store")
-			return fmt.Errorf("inserting signal: %w", err)
+			return fmt.Errorf("inserting signal: %w", mapError(err, domain.EntitySignal, strconv.Itoa(signal.ID)))
+		}
+		if res.RowsAffected() == 1 {
+			return nil
+		}
+
+		existing := &domain.Signal{ID: signal.ID}
+		if err := tx.ModelContext(ctx, existing).WherePK().For("UPDATE").Select(); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("getting existing signal from store")
+			return fmt.Errorf("getting existing signal: %w", err)
+		}
+
+		outcome, err = policy.Resolve(*existing == *signal)
+		if err != nil {
+			return &domain.ConflictError{Entity: domain.EntitySignal, Reason: fmt.Sprintf("signal %d already exists with a different name, ELR or kind", signal.ID)}
+		}
+		if outcome != domain.WriteUpdated {
+			return nil
+		}
+
+		if _, err := tx.ModelContext(ctx, signal).WherePK().Update(); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("overwriting signal")
+			return fmt.Errorf("overwriting signal: %w", mapError(err, domain.EntitySignal, strconv.Itoa(signal.ID)))
 		}
 
 		return nil
 	})
+	if err != nil {
+		return "", err
+	}
+
+	return outcome, nil
 }
 
 // GetSignal retrieves a signal by its ID.
 func (r *PostgresRepository) GetSignal(ctx context.Context, signalID int) (*domain.Signal, error) {
 	signal := &domain.Signal{ID: signalID}
-	err := r.db.Model(signal).WherePK().Select()
+	err := r.conn().ModelContext(ctx, signal).WherePK().Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntitySignal, strconv.Itoa(signalID))
+	}
 	if err != nil {
 		r.logger.WithContext(ctx).WithError(err).Error("getting signal from store")
 		return nil, fmt.Errorf("getting signal: %w", err)
@@ -34,12 +67,27 @@ func (r *PostgresRepository) GetSignal(ctx context.Context, signalID int) (*doma
 	return signal, nil
 }
 
-// ListSignals retrieves all signals from the database.
+// ListSignals retrieves the signals matching the filter from the database.
 // Handles paginated requests and returns the total count along with the returned signals.
-func (r *PostgresRepository) ListSignals(ctx context.Context, limit, page int) ([]domain.Signal, int, error) {
+func (r *PostgresRepository) ListSignals(ctx context.Context, filter domain.SignalFilter, limit, page int) ([]domain.Signal, int, error) {
 	var signals []domain.Signal
 
-	count, err := r.db.Model(&signals).
+	query := r.conn().ModelContext(ctx, &signals)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Aspects != 0 {
+		query = query.Where("aspects = ?", filter.Aspects)
+	}
+	if filter.RouteIndicator != "" {
+		query = query.Where("route_indicator = ?", filter.RouteIndicator)
+	}
+	if filter.Automatic != nil {
+		query = query.Where("automatic = ?", *filter.Automatic)
+	}
+
+	count, err := query.
+		Order("id ASC").
 		Limit(limit).
 		Offset(page * limit).
 		SelectAndCount()
@@ -51,30 +99,51 @@ func (r *PostgresRepository) ListSignals(ctx context.Context, limit, page int) (
 	return signals, count, nil
 }
 
+// ListUnplacedSignals retrieves the signals that aren't on any track from the database ordered by ID.
+// Handles paginated requests and returns the total count along with the returned signals.
+func (r *PostgresRepository) ListUnplacedSignals(ctx context.Context, limit, page int) ([]domain.Signal, int, error) {
+	var signals []domain.Signal
+
+	count, err := r.conn().ModelContext(ctx, &signals).
+		Where("NOT EXISTS (SELECT 1 FROM mileages AS m WHERE m.signal_id = signal.id)").
+		Order("id ASC").
+		Limit(limit).
+		Offset(page * limit).
+		SelectAndCount()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing unplaced signals from store")
+		return nil, 0, fmt.Errorf("listing unplaced signals: %w", err)
+	}
+
+	return signals, count, nil
+}
+
 // UpdateSignal modifies an existing signal.
 func (r *PostgresRepository) UpdateSignal(ctx context.Context, updateReq *domain.Signal) error {
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := r.db.Model(updateReq).WherePK().Update()
+	return r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, updateReq).WherePK().Update()
 		if err != nil {
 			r.logger.WithContext(ctx).WithError(err).Error("updating signal")
-			return fmt.Errorf("updating signal: %w", err)
+			return fmt.Errorf("updating signal: %w", mapError(err, domain.EntitySignal, strconv.Itoa(updateReq.ID)))
+		}
+		if res.RowsAffected() == 0 {
+			return &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(updateReq.ID)}
 		}
 
 		return nil
 	})
 }
 
-// DeleteSignal removes a signal from the database.
+// DeleteSignal removes a signal from the database, returning domain.ErrNotFound if it doesn't exist.
 func (r *PostgresRepository) DeleteSignal(ctx context.Context, signalID int) error {
-	signal := &domain.Signal{ID: signalID}
-
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := r.db.Model(signal).WherePK().Delete()
-		if err != nil && !errors.Is(err, pg.ErrNoRows) {
-			r.logger.WithContext(ctx).WithError(err).Error("deleting signal")
-			return fmt.Errorf("deleting signal: %w", err)
-		}
+	res, err := r.conn().ModelContext(ctx, &domain.Signal{ID: signalID}).WherePK().Delete()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("deleting signal")
+		return fmt.Errorf("deleting signal: %w", mapError(err, domain.EntitySignal, strconv.Itoa(signalID)))
+	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntitySignal, Key: strconv.Itoa(signalID)}
+	}
 
-		return err
-	})
+	return nil
 }
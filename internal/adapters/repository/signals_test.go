@@ -19,14 +19,19 @@ func TestCreateSignals(t *testing.T) {
 			req: &domain.Signal{
 				ID:   1,
 				Name: "signal",
-				ELR:  "asdf",
+				ELR:  "ABC",
 			},
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			err := testDB.CreateSignal(context.Background(), test.req)
+			_, err := underlyingDB.Exec("TRUNCATE points, routes, mileages, tracks, signals, elrs")
+			require.NoError(t, err, "truncating tables")
+			_, err = testDB.CreateELR(context.Background(), &domain.ELR{Code: "ABC"}, domain.ConflictFail)
+			require.NoError(t, err, "registering ELR")
+
+			_, err = testDB.CreateSignal(context.Background(), test.req, domain.ConflictFail)
 			if test.errorContains != "" {
 				require.ErrorContains(t, err, test.errorContains, "create error contains")
 				return
@@ -4,28 +4,62 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/go-pg/pg/v10"
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
 // CreateTrack inserts a new track into the database.
-func (r *PostgresRepository) CreateTrack(ctx context.Context, track *domain.Track) error {
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := tx.ModelContext(ctx, track).OnConflict("DO NOTHING").Insert()
+// When a track with the same ID exists the conflict policy decides whether it is kept, overwritten or rejected.
+func (r *PostgresRepository) CreateTrack(ctx context.Context, track *domain.Track, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	outcome := domain.WriteInserted
+	err := r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, track).OnConflict("DO NOTHING").Insert()
 		if err != nil {
 			r.logger.WithContext(ctx).WithError(err).Error("inserting track into store")
-			return fmt.Errorf("inserting track: %w", err)
+			return fmt.Errorf("inserting track: %w", mapError(err, domain.EntityTrack, strconv.Itoa(track.ID)))
 		}
+		if res.RowsAffected() == 1 {
+			return nil
+		}
+
+		existing := &domain.Track{ID: track.ID}
+		if err := tx.ModelContext(ctx, existing).WherePK().For("UPDATE").Select(); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("getting existing track from store")
+			return fmt.Errorf("getting existing track: %w", err)
+		}
+
+		outcome, err = policy.Resolve(*existing == *track)
+		if err != nil {
+			return &domain.ConflictError{Entity: domain.EntityTrack, Reason: fmt.Sprintf("track %d already exists with a different source or target", track.ID)}
+		}
+		if outcome != domain.WriteUpdated {
+			return nil
+		}
+
+		if _, err := tx.ModelContext(ctx, track).WherePK().Update(); err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("overwriting track")
+			return fmt.Errorf("overwriting track: %w", mapError(err, domain.EntityTrack, strconv.Itoa(track.ID)))
+		}
+
 		return nil
 	})
+	if err != nil {
+		return "", err
+	}
+
+	return outcome, nil
 }
 
 // GetTrack retrieves a track by its ID.
 func (r *PostgresRepository) GetTrack(ctx context.Context, trackID int) (*domain.Track, error) {
 	track := &domain.Track{ID: trackID}
 
-	err := r.db.ModelContext(ctx, track).WherePK().Select()
+	err := r.conn().ModelContext(ctx, track).WherePK().Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, mapError(err, domain.EntityTrack, strconv.Itoa(trackID))
+	}
 	if err != nil {
 		r.logger.WithContext(ctx).WithError(err).Error("getting track from store")
 		return nil, fmt.Errorf("getting track: %w", err)
@@ -38,7 +72,8 @@ func (r *PostgresRepository) GetTrack(ctx context.Context, trackID int) (*domain
 // Handles paginated requests and returns the total count along with the returned tracks.
 func (r *PostgresRepository) ListTracks(ctx context.Context, limit, page int) ([]domain.Track, int, error) {
 	var tracks []domain.Track
-	count, err := r.db.ModelContext(ctx, &tracks).
+	count, err := r.conn().ModelContext(ctx, &tracks).
+		Order("id ASC").
 		Limit(limit).
 		Offset(page * limit).
 		SelectAndCount()
@@ -52,49 +87,130 @@ func (r *PostgresRepository) ListTracks(ctx context.Context, limit, page int) ([
 
 // UpdateTrack modifies an existing track.
 func (r *PostgresRepository) UpdateTrack(ctx context.Context, track *domain.Track) error {
-	return r.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
-		_, err := tx.ModelContext(ctx, track).WherePK().Update()
+	return r.runInTransaction(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, track).WherePK().Update()
 		if err != nil {
 			r.logger.WithContext(ctx).WithError(err).Error("updating track")
-			return fmt.Errorf("updating track: %w", err)
+			return fmt.Errorf("updating track: %w", mapError(err, domain.EntityTrack, strconv.Itoa(track.ID)))
+		}
+		if res.RowsAffected() == 0 {
+			return &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(track.ID)}
 		}
 
 		return nil
 	})
 }
 
-// DeleteTrack removes a track from the database.
+// DeleteTrack removes a track from the database, its mileages and the points that switch onto it go with it.
+// Returns domain.ErrNotFound if the track doesn't exist.
 func (r *PostgresRepository) DeleteTrack(ctx context.Context, trackID int) error {
-	track := &domain.Track{ID: trackID}
-	_, err := r.db.ModelContext(ctx, track).Table("tracks").WherePK().Delete()
-	if err != nil && !errors.Is(err, pg.ErrNoRows) {
+	res, err := r.conn().ModelContext(ctx, &domain.Track{ID: trackID}).Table("tracks").WherePK().Delete()
+	if err != nil {
 		r.logger.WithContext(ctx).WithError(err).Error("deleting track")
 		return fmt.Errorf("deleting track: %w", err)
 	}
+	if res.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityTrack, Key: strconv.Itoa(trackID)}
+	}
 
-	return err
+	return nil
 }
 
 // ListSignalTracks retrieves all tracks from the database associated with the given signal.
 // Handles paginated requests and returns the total count along with the returned tracks.
 func (r *PostgresRepository) ListSignalTracks(ctx context.Context, signalID, limit, page int) ([]domain.Track, int, error) {
 	var tracks []domain.Track
-	err := r.db.ModelContext(ctx, &tracks).
+	count, err := r.conn().ModelContext(ctx, &tracks).
+		Join("JOIN mileages AS m ON m.track_id = track.id").
+		Where("m.signal_id = ?", signalID).
+		Order("track.id ASC").
 		Limit(limit).
-		Offset(page*limit).
-		Join("mileage ON tracks.id=mileage.track_id").
-		Where("mileage.signal_id = ?", signalID).
-		Select()
+		Offset(page * limit).
+		SelectAndCount()
 	if err != nil {
-		r.logger.WithContext(ctx).WithError(err).Error("listing tracks from store")
-		return nil, 0, fmt.Errorf("listing signals: %w", err)
+		r.logger.WithContext(ctx).WithError(err).Error("listing signal tracks from store")
+		return nil, 0, fmt.Errorf("listing signal tracks: %w", err)
 	}
 
-	count, err := r.db.ModelContext(ctx, (*domain.Track)(nil)).Table("tracks").Count()
+	return tracks, count, nil
+}
+
+// ListTrackSignals retrieves tracks from the database along with their signals ordered by mileage.
+// Handles paginated requests and returns the total count along with the returned tracks.
+func (r *PostgresRepository) ListTrackSignals(ctx context.Context, limit, page int) ([]domain.TrackSignals, int, error) {
+	tracks, count, err := r.ListTracks(ctx, limit, page)
 	if err != nil {
-		r.logger.WithContext(ctx).WithError(err).Error("counting tracks from store")
-		return nil, 0, fmt.Errorf("counting tracks: %w", err)
+		return nil, 0, err
+	}
+	if len(tracks) == 0 {
+		return []domain.TrackSignals{}, count, nil
 	}
 
-	return tracks, count, nil
+	trackIDs := make([]int, 0, len(tracks))
+	for _, t := range tracks {
+		trackIDs = append(trackIDs, t.ID)
+	}
+
+	signals, err := r.listSignalsOnTracks(ctx, trackIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	trackSignals := make([]domain.TrackSignals, 0, len(tracks))
+	for _, t := range tracks {
+		trackSignals = append(trackSignals, domain.TrackSignals{
+			ID:      t.ID,
+			Source:  t.Source,
+			Target:  t.Target,
+			Signals: signals[t.ID],
+		})
+	}
+
+	return trackSignals, count, nil
+}
+
+// GetTrackSignals retrieves a track by its ID along with its signals ordered by mileage.
+func (r *PostgresRepository) GetTrackSignals(ctx context.Context, trackID int) (*domain.TrackSignals, error) {
+	track, err := r.GetTrack(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	signals, err := r.listSignalsOnTracks(ctx, []int{trackID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TrackSignals{
+		ID:      track.ID,
+		Source:  track.Source,
+		Target:  track.Target,
+		Signals: signals[trackID],
+	}, nil
+}
+
+// listSignalsOnTracks joins the mileages and signals of the given tracks.
+// Returns the signals of each track ordered by mileage, keyed by track ID.
+func (r *PostgresRepository) listSignalsOnTracks(ctx context.Context, trackIDs []int) (map[int][]domain.TrackSignal, error) {
+	var rows []struct {
+		TrackID int
+		domain.TrackSignal
+	}
+	_, err := r.conn().QueryContext(ctx, &rows, `
+		SELECT m.track_id, s.id, s.name, s.elr, m.mileage, s.type, s.aspects, s.route_indicator, s.automatic
+		FROM mileages AS m
+		JOIN signals AS s ON s.id = m.signal_id
+		WHERE m.track_id IN (?)
+		ORDER BY m.track_id, m.mileage, s.id`, pg.In(trackIDs))
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("listing track signals from store")
+		return nil, fmt.Errorf("listing track signals: %w", err)
+	}
+
+	signals := make(map[int][]domain.TrackSignal, len(trackIDs))
+	for _, row := range rows {
+		signals[row.TrackID] = append(signals[row.TrackID], row.TrackSignal)
+	}
+
+	return signals, nil
 }
@@ -26,7 +26,10 @@ func TestCreateTrack(t *testing.T) {
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			err := testDB.CreateTrack(context.Background(), test.req)
+			_, err := underlyingDB.Exec("TRUNCATE points, routes, mileages, tracks, signals, elrs")
+			require.NoError(t, err, "truncating tables")
+
+			_, err = testDB.CreateTrack(context.Background(), test.req, domain.ConflictFail)
 			if test.errorContains != "" {
 				require.ErrorContains(t, err, test.errorContains, "create error contains")
 				return
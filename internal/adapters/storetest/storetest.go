@@ -0,0 +1,1026 @@
+// Package storetest provides a conformance suite for implementations of the domain stores.
+//
+// Adapters prove they are compatible by running the suite from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storetest.Stores {
+//			repo := newEmptyRepository(t)
+//			return storetest.Stores{Signals: repo, Tracks: repo, Mileages: repo, ELRs: repo, Routes: repo, Points: repo, Transactor: repo}
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// Stores groups the stores under test.
+type Stores struct {
+	Signals    domain.SignalStore
+	Tracks     domain.TrackStore
+	Mileages   domain.MileageStore
+	ELRs       domain.ELRStore
+	Routes     domain.RouteStore
+	Points     domain.PointStore
+	Transactor domain.Transactor
+}
+
+// Factory returns stores that share the same underlying data and start out empty.
+// It is called once per test case.
+type Factory func(t *testing.T) Stores
+
+// Run checks the stores returned by newStores against the store contract.
+// Every test case starts with the ELR "ABC" registered, the signals in the suite are on it.
+func Run(t *testing.T, factory Factory) {
+	newStores := func(t *testing.T) Stores {
+		stores := factory(t)
+		createELR(t, stores.ELRs, &domain.ELR{Code: "ABC"})
+		return stores
+	}
+
+	t.Run("signals", func(t *testing.T) { testSignals(t, newStores) })
+	t.Run("tracks", func(t *testing.T) { testTracks(t, newStores) })
+	t.Run("mileages", func(t *testing.T) { testMileages(t, newStores) })
+	t.Run("mileage lists", func(t *testing.T) { testMileageLists(t, newStores) })
+	t.Run("pagination", func(t *testing.T) { testPagination(t, newStores) })
+	t.Run("signal tracks", func(t *testing.T) { testSignalTracks(t, newStores) })
+	t.Run("track signals", func(t *testing.T) { testTrackSignals(t, newStores) })
+	t.Run("conflicts", func(t *testing.T) { testConflicts(t, newStores) })
+	t.Run("transactions", func(t *testing.T) { testTransactions(t, newStores) })
+	t.Run("elrs", func(t *testing.T) { testELRs(t, newStores) })
+	t.Run("routes", func(t *testing.T) { testRoutes(t, newStores) })
+	t.Run("points", func(t *testing.T) { testPoints(t, newStores) })
+}
+
+func testSignals(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		stores := newStores(t)
+		signal := &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"}
+
+		createSignal(t, stores.Signals, signal)
+
+		got, err := stores.Signals.GetSignal(ctx, signal.ID)
+		require.NoError(t, err, "getting signal")
+		assert.Equal(t, signal, got, "signal")
+	})
+
+	t.Run("get missing signal", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Signals.GetSignal(ctx, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing signal")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		stores := newStores(t)
+		createELR(t, stores.ELRs, &domain.ELR{Code: "XYZ"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+
+		updated := &domain.Signal{ID: 1, Name: "SIG2", ELR: "XYZ", SignalKind: domain.SignalKind{
+			Type: domain.SignalMain, Aspects: 3, RouteIndicator: domain.RouteIndicatorJunction, Automatic: true,
+		}}
+		require.NoError(t, stores.Signals.UpdateSignal(ctx, updated), "updating signal")
+
+		got, err := stores.Signals.GetSignal(ctx, 1)
+		require.NoError(t, err, "getting signal")
+		assert.Equal(t, updated, got, "signal")
+	})
+
+	t.Run("list filtered by kind", func(t *testing.T) {
+		stores := newStores(t)
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 2, ELR: "ABC", SignalKind: domain.SignalKind{Type: domain.SignalMain, Aspects: 4, Automatic: true}})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 3, ELR: "ABC", SignalKind: domain.SignalKind{Type: domain.SignalMain, Aspects: 3}})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 4, ELR: "ABC", SignalKind: domain.SignalKind{Type: domain.SignalShunt, RouteIndicator: domain.RouteIndicatorTheatre}})
+
+		controlled := false
+		filters := map[string]struct {
+			filter  domain.SignalFilter
+			wantIDs []int
+		}{
+			"everything":      {filter: domain.SignalFilter{}, wantIDs: []int{1, 2, 3, 4}},
+			"type":            {filter: domain.SignalFilter{Type: domain.SignalMain}, wantIDs: []int{2, 3}},
+			"aspects":         {filter: domain.SignalFilter{Aspects: 3}, wantIDs: []int{3}},
+			"route indicator": {filter: domain.SignalFilter{RouteIndicator: domain.RouteIndicatorTheatre}, wantIDs: []int{4}},
+			"controlled main": {filter: domain.SignalFilter{Type: domain.SignalMain, Automatic: &controlled}, wantIDs: []int{3}},
+		}
+		for name, test := range filters {
+			signals, count, err := stores.Signals.ListSignals(ctx, test.filter, 0, 0)
+			require.NoError(t, err, "listing signals by %s", name)
+			assert.Equal(t, len(test.wantIDs), count, "signal count by %s", name)
+			assert.Equal(t, test.wantIDs, signalIDs(signals), "signal ids by %s", name)
+		}
+	})
+
+	t.Run("signal without an elr", func(t *testing.T) {
+		stores := newStores(t)
+		signal := &domain.Signal{ID: 1, Name: "SIG1"}
+
+		createSignal(t, stores.Signals, signal)
+
+		got, err := stores.Signals.GetSignal(ctx, 1)
+		require.NoError(t, err, "getting signal")
+		assert.Equal(t, signal, got, "signal")
+	})
+
+	t.Run("update missing signal", func(t *testing.T) {
+		stores := newStores(t)
+
+		err := stores.Signals.UpdateSignal(ctx, &domain.Signal{ID: 404, Name: "SIG1", ELR: "ABC"})
+		require.ErrorIs(t, err, domain.ErrNotFound, "updating missing signal")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		stores := newStores(t)
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+
+		require.NoError(t, stores.Signals.DeleteSignal(ctx, 1), "deleting signal")
+		require.ErrorIs(t, stores.Signals.DeleteSignal(ctx, 1), domain.ErrNotFound, "deleting missing signal")
+
+		_, err := stores.Signals.GetSignal(ctx, 1)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting deleted signal")
+	})
+
+	t.Run("delete signal with mileages fails", func(t *testing.T) {
+		stores := newStores(t)
+		seedTrackSignal(t, stores, 1, 1, 1.5)
+
+		require.ErrorIs(t, stores.Signals.DeleteSignal(ctx, 1), domain.ErrConflict, "deleting referenced signal")
+
+		_, err := stores.Signals.GetSignal(ctx, 1)
+		require.NoError(t, err, "getting referenced signal")
+	})
+
+	invalid := map[string]*domain.Signal{
+		"missing id": {Name: "SIG1", ELR: "ABC"},
+		"long elr":   {ID: 1, Name: "SIG1", ELR: "ABCDE"},
+	}
+	for name, signal := range invalid {
+		t.Run("create rejects "+name, func(t *testing.T) {
+			stores := newStores(t)
+
+			_, err := stores.Signals.CreateSignal(ctx, signal, domain.ConflictFail)
+			require.ErrorIs(t, err, domain.ErrValidation, "creating invalid signal")
+		})
+	}
+}
+
+func testTracks(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		stores := newStores(t)
+		track := &domain.Track{ID: 1, Source: "A", Target: "B"}
+
+		createTrack(t, stores.Tracks, track)
+
+		got, err := stores.Tracks.GetTrack(ctx, track.ID)
+		require.NoError(t, err, "getting track")
+		assert.Equal(t, track, got, "track")
+	})
+
+	t.Run("get missing track", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Tracks.GetTrack(ctx, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing track")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		stores := newStores(t)
+		createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+
+		updated := &domain.Track{ID: 1, Source: "C", Target: "D"}
+		require.NoError(t, stores.Tracks.UpdateTrack(ctx, updated), "updating track")
+
+		got, err := stores.Tracks.GetTrack(ctx, 1)
+		require.NoError(t, err, "getting track")
+		assert.Equal(t, updated, got, "track")
+	})
+
+	t.Run("update missing track", func(t *testing.T) {
+		stores := newStores(t)
+
+		err := stores.Tracks.UpdateTrack(ctx, &domain.Track{ID: 404, Source: "A", Target: "B"})
+		require.ErrorIs(t, err, domain.ErrNotFound, "updating missing track")
+	})
+
+	t.Run("delete cascades to mileages", func(t *testing.T) {
+		stores := newStores(t)
+		seedTrackSignal(t, stores, 1, 1, 1.5)
+
+		require.NoError(t, stores.Tracks.DeleteTrack(ctx, 1), "deleting track")
+		require.ErrorIs(t, stores.Tracks.DeleteTrack(ctx, 1), domain.ErrNotFound, "deleting missing track")
+
+		_, err := stores.Tracks.GetTrack(ctx, 1)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting deleted track")
+
+		tracks, count, err := stores.Tracks.ListSignalTracks(ctx, 1, 10, 0)
+		require.NoError(t, err, "listing signal tracks")
+		assert.Empty(t, tracks, "signal tracks")
+		assert.Zero(t, count, "signal track count")
+
+		// The signal is no longer referenced so it can now be deleted.
+		require.NoError(t, stores.Signals.DeleteSignal(ctx, 1), "deleting signal")
+	})
+
+	invalid := map[string]*domain.Track{
+		"missing id":     {Source: "A", Target: "B"},
+		"missing source": {ID: 1, Target: "B"},
+		"missing target": {ID: 1, Source: "A"},
+	}
+	for name, track := range invalid {
+		t.Run("create rejects "+name, func(t *testing.T) {
+			stores := newStores(t)
+
+			_, err := stores.Tracks.CreateTrack(ctx, track, domain.ConflictFail)
+			require.ErrorIs(t, err, domain.ErrValidation, "creating invalid track")
+		})
+	}
+}
+
+func testMileages(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		mileage *domain.Mileage
+
+		wantErr bool
+	}{
+		"signal on a second track": {
+			mileage: &domain.Mileage{SignalID: 1, TrackID: 2, Mileage: domain.Miles(3.25)},
+		},
+		"signal at mileage zero": {
+			mileage: &domain.Mileage{SignalID: 1, TrackID: 2, Mileage: 0},
+		},
+		"duplicate signal on the same track": {
+			mileage: &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(3.25)},
+			wantErr: true,
+		},
+		"missing signal": {
+			mileage: &domain.Mileage{SignalID: 404, TrackID: 1, Mileage: domain.Miles(3.25)},
+			wantErr: true,
+		},
+		"missing track": {
+			mileage: &domain.Mileage{SignalID: 1, TrackID: 404, Mileage: domain.Miles(3.25)},
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stores := newStores(t)
+			seedTrackSignal(t, stores, 1, 1, 1.5)
+			createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "B", Target: "C"})
+
+			_, err := stores.Mileages.AddMileage(ctx, test.mileage, domain.ConflictFail)
+			if test.wantErr {
+				require.Error(t, err, "adding mileage")
+				return
+			}
+			require.NoError(t, err, "adding mileage")
+
+			got, err := stores.Mileages.GetMileage(ctx, test.mileage.SignalID, test.mileage.TrackID)
+			require.NoError(t, err, "getting mileage")
+			assert.Equal(t, test.mileage, got, "mileage")
+		})
+	}
+
+	t.Run("get", func(t *testing.T) {
+		stores := newStores(t)
+		seedTrackSignal(t, stores, 1, 1, 1.5)
+
+		got, err := stores.Mileages.GetMileage(ctx, 1, 1)
+		require.NoError(t, err, "getting mileage")
+		assert.Equal(t, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(1.5)}, got, "mileage")
+
+		_, err = stores.Mileages.GetMileage(ctx, 1, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing mileage")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		stores := newStores(t)
+		seedTrackSignal(t, stores, 1, 1, 1.5)
+
+		require.NoError(t, stores.Mileages.DeleteMileage(ctx, 1, 1), "deleting mileage")
+		require.ErrorIs(t, stores.Mileages.DeleteMileage(ctx, 1, 1), domain.ErrNotFound, "deleting missing mileage")
+
+		track, err := stores.Tracks.GetTrackSignals(ctx, 1)
+		require.NoError(t, err, "getting track signals")
+		assert.Empty(t, track.Signals, "track signals")
+
+		// The signal is no longer referenced so it can now be deleted.
+		require.NoError(t, stores.Signals.DeleteSignal(ctx, 1), "deleting signal")
+	})
+}
+
+func testMileageLists(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+	stores := newStores(t)
+
+	// Signals 1 and 2 are on track 1 in decreasing ID order, signal 1 is also on track 2.
+	createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+	createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "B", Target: "C"})
+	createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+	createSignal(t, stores.Signals, &domain.Signal{ID: 2, Name: "SIG2", ELR: "ABC"})
+	addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(2)})
+	addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 2, TrackID: 1, Mileage: domain.Miles(1)})
+	addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 1, TrackID: 2, Mileage: domain.Miles(5)})
+
+	tests := map[string]struct {
+		list            func(ctx context.Context, id, limit, page int) ([]domain.Mileage, int, error)
+		id, limit, page int
+
+		want      []domain.Mileage
+		wantCount int
+	}{
+		"track ordered by mileage": {
+			list: stores.Mileages.ListTrackMileages, id: 1, limit: 10,
+			want: []domain.Mileage{
+				{SignalID: 2, TrackID: 1, Mileage: domain.Miles(1)},
+				{SignalID: 1, TrackID: 1, Mileage: domain.Miles(2)},
+			},
+			wantCount: 2,
+		},
+		"track paginated": {
+			list: stores.Mileages.ListTrackMileages, id: 1, limit: 1, page: 1,
+			want:      []domain.Mileage{{SignalID: 1, TrackID: 1, Mileage: domain.Miles(2)}},
+			wantCount: 2,
+		},
+		"track without signals": {
+			list: stores.Mileages.ListTrackMileages, id: 404, limit: 10,
+			want: []domain.Mileage{},
+		},
+		"signal ordered by track": {
+			list: stores.Mileages.ListSignalMileages, id: 1, limit: 10,
+			want: []domain.Mileage{
+				{SignalID: 1, TrackID: 1, Mileage: domain.Miles(2)},
+				{SignalID: 1, TrackID: 2, Mileage: domain.Miles(5)},
+			},
+			wantCount: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mileages, count, err := test.list(ctx, test.id, test.limit, test.page)
+			require.NoError(t, err, "listing mileages")
+			assert.Equal(t, test.wantCount, count, "mileage count")
+			assert.Equal(t, test.want, mileages, "mileages")
+		})
+	}
+}
+
+func testPagination(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+	stores := newStores(t)
+
+	for i := 1; i <= 5; i++ {
+		createSignal(t, stores.Signals, &domain.Signal{ID: i, Name: "SIG", ELR: "ABC"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: i, Source: "A", Target: "B"})
+	}
+
+	tests := map[string]struct {
+		limit, page int
+
+		wantIDs []int
+	}{
+		"first page": {
+			limit: 2, page: 0,
+			wantIDs: []int{1, 2},
+		},
+		"middle page": {
+			limit: 2, page: 1,
+			wantIDs: []int{3, 4},
+		},
+		"last partial page": {
+			limit: 2, page: 2,
+			wantIDs: []int{5},
+		},
+		"last full page": {
+			limit: 5, page: 0,
+			wantIDs: []int{1, 2, 3, 4, 5},
+		},
+		"past the end": {
+			limit: 2, page: 3,
+			wantIDs: []int{},
+		},
+		"no limit": {
+			limit: 0, page: 0,
+			wantIDs: []int{1, 2, 3, 4, 5},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			signals, count, err := stores.Signals.ListSignals(ctx, domain.SignalFilter{}, test.limit, test.page)
+			require.NoError(t, err, "listing signals")
+			assert.Equal(t, 5, count, "signal count")
+			assert.Equal(t, test.wantIDs, signalIDs(signals), "signal ids")
+
+			tracks, count, err := stores.Tracks.ListTracks(ctx, test.limit, test.page)
+			require.NoError(t, err, "listing tracks")
+			assert.Equal(t, 5, count, "track count")
+			assert.Equal(t, test.wantIDs, trackIDs(tracks), "track ids")
+		})
+	}
+}
+
+func testSignalTracks(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+	stores := newStores(t)
+
+	// Signal 1 is on tracks 1, 2 and 3, signal 2 only on track 2 and signal 3 on no track.
+	for i := 1; i <= 3; i++ {
+		createSignal(t, stores.Signals, &domain.Signal{ID: i, Name: "SIG", ELR: "ABC"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: i, Source: "A", Target: "B"})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 1, TrackID: i, Mileage: domain.Miles(float64(i))})
+	}
+	addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 2, TrackID: 2, Mileage: domain.Miles(1)})
+
+	tests := map[string]struct {
+		signalID, limit, page int
+
+		wantIDs   []int
+		wantCount int
+	}{
+		"signal on every track": {
+			signalID: 1, limit: 10,
+			wantIDs:   []int{1, 2, 3},
+			wantCount: 3,
+		},
+		"paginated": {
+			signalID: 1, limit: 2, page: 1,
+			wantIDs:   []int{3},
+			wantCount: 3,
+		},
+		"signal on one track": {
+			signalID: 2, limit: 10,
+			wantIDs:   []int{2},
+			wantCount: 1,
+		},
+		"signal on no track": {
+			signalID: 3, limit: 10,
+			wantIDs: []int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracks, count, err := stores.Tracks.ListSignalTracks(ctx, test.signalID, test.limit, test.page)
+			require.NoError(t, err, "listing signal tracks")
+			assert.Equal(t, test.wantCount, count, "signal track count")
+			assert.Equal(t, test.wantIDs, trackIDs(tracks), "signal track ids")
+		})
+	}
+
+	t.Run("signals on no track", func(t *testing.T) {
+		createSignal(t, stores.Signals, &domain.Signal{ID: 4, Name: "SIG", ELR: "ABC"})
+
+		signals, count, err := stores.Signals.ListUnplacedSignals(ctx, 1, 1)
+		require.NoError(t, err, "listing unplaced signals")
+		assert.Equal(t, 2, count, "unplaced signal count")
+		assert.Equal(t, []int{4}, signalIDs(signals), "unplaced signal ids")
+	})
+}
+
+func testTrackSignals(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+	stores := newStores(t)
+
+	createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+	createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "B", Target: "C"})
+	for i, mileage := range []float64{3.5, 1.25, 2} {
+		signal := &domain.Signal{ID: i + 1, Name: "SIG", ELR: "ABC"}
+		createSignal(t, stores.Signals, signal)
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: signal.ID, TrackID: 1, Mileage: domain.Miles(mileage)})
+	}
+
+	tests := map[string]struct {
+		limit, page int
+
+		want []domain.TrackSignals
+	}{
+		"signals ordered by mileage": {
+			limit: 1, page: 0,
+			want: []domain.TrackSignals{{
+				ID: 1, Source: "A", Target: "B",
+				Signals: []domain.TrackSignal{
+					{ID: 2, Name: "SIG", ELR: "ABC", Mileage: miles(1.25)},
+					{ID: 3, Name: "SIG", ELR: "ABC", Mileage: miles(2)},
+					{ID: 1, Name: "SIG", ELR: "ABC", Mileage: miles(3.5)},
+				},
+			}},
+		},
+		"track without signals": {
+			limit: 1, page: 1,
+			want: []domain.TrackSignals{{ID: 2, Source: "B", Target: "C"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracks, count, err := stores.Tracks.ListTrackSignals(ctx, test.limit, test.page)
+			require.NoError(t, err, "listing track signals")
+			assert.Equal(t, 2, count, "track count")
+			assert.Equal(t, test.want, tracks, "track signals")
+
+			track, err := stores.Tracks.GetTrackSignals(ctx, test.want[0].ID)
+			require.NoError(t, err, "getting track signals")
+			assert.Equal(t, &test.want[0], track, "track signals")
+		})
+	}
+
+	t.Run("get missing track", func(t *testing.T) {
+		_, err := stores.Tracks.GetTrackSignals(ctx, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing track signals")
+	})
+}
+
+func testConflicts(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	// Each entity is written as version 1, then written again as the given version.
+	// The versions differ in a field that isn't part of the key.
+	entities := map[string]struct {
+		setup   func(t *testing.T, stores Stores)
+		write   func(stores Stores, version int, policy domain.ConflictPolicy) (domain.WriteOutcome, error)
+		version func(t *testing.T, stores Stores) int
+	}{
+		"signal": {
+			write: func(stores Stores, version int, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+				return stores.Signals.CreateSignal(ctx, &domain.Signal{ID: 1, Name: fmt.Sprintf("SIG%d", version), ELR: "ABC"}, policy)
+			},
+			version: func(t *testing.T, stores Stores) int {
+				signal, err := stores.Signals.GetSignal(ctx, 1)
+				require.NoError(t, err, "getting signal")
+				return int(signal.Name[len(signal.Name)-1] - '0')
+			},
+		},
+		"track": {
+			write: func(stores Stores, version int, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+				return stores.Tracks.CreateTrack(ctx, &domain.Track{ID: 1, Source: "A", Target: fmt.Sprintf("B%d", version)}, policy)
+			},
+			version: func(t *testing.T, stores Stores) int {
+				track, err := stores.Tracks.GetTrack(ctx, 1)
+				require.NoError(t, err, "getting track")
+				return int(track.Target[len(track.Target)-1] - '0')
+			},
+		},
+		"mileage": {
+			setup: func(t *testing.T, stores Stores) {
+				createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG", ELR: "ABC"})
+				createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+			},
+			write: func(stores Stores, version int, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+				return stores.Mileages.AddMileage(ctx, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Distance(version)}, policy)
+			},
+			version: func(t *testing.T, stores Stores) int {
+				track, err := stores.Tracks.GetTrackSignals(ctx, 1)
+				require.NoError(t, err, "getting track signals")
+				require.Len(t, track.Signals, 1, "track signals")
+				return int(*track.Signals[0].Mileage)
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		policy  domain.ConflictPolicy
+		version int
+
+		wantOutcome domain.WriteOutcome
+		wantErr     error
+		wantVersion int
+	}{
+		"skip keeps the stored entity": {
+			policy: domain.ConflictSkip, version: 2,
+			wantOutcome: domain.WriteSkipped,
+			wantVersion: 1,
+		},
+		"upsert overwrites the stored entity": {
+			policy: domain.ConflictUpsert, version: 2,
+			wantOutcome: domain.WriteUpdated,
+			wantVersion: 2,
+		},
+		"fail rejects the write": {
+			policy: domain.ConflictFail, version: 2,
+			wantErr:     domain.ErrConflict,
+			wantVersion: 1,
+		},
+		"identical entity is skipped": {
+			policy: domain.ConflictFail, version: 1,
+			wantOutcome: domain.WriteSkipped,
+			wantVersion: 1,
+		},
+		"identical entity is not updated": {
+			policy: domain.ConflictUpsert, version: 1,
+			wantOutcome: domain.WriteSkipped,
+			wantVersion: 1,
+		},
+	}
+
+	for entityName, entity := range entities {
+		for name, test := range tests {
+			t.Run(entityName+" "+name, func(t *testing.T) {
+				stores := newStores(t)
+				if entity.setup != nil {
+					entity.setup(t, stores)
+				}
+
+				outcome, err := entity.write(stores, 1, domain.ConflictFail)
+				require.NoError(t, err, "writing original")
+				require.Equal(t, domain.WriteInserted, outcome, "original outcome")
+
+				outcome, err = entity.write(stores, test.version, test.policy)
+				if test.wantErr != nil {
+					require.ErrorIs(t, err, test.wantErr, "writing again")
+				} else {
+					require.NoError(t, err, "writing again")
+					assert.Equal(t, test.wantOutcome, outcome, "outcome")
+				}
+
+				assert.Equal(t, test.wantVersion, entity.version(t, stores), "stored version")
+			})
+		}
+	}
+}
+
+func testTransactions(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	t.Run("commit keeps every write", func(t *testing.T) {
+		stores := newStores(t)
+
+		err := stores.Transactor.InTransaction(ctx, func(ctx context.Context, tx domain.Tx) error {
+			createSignal(t, tx, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+			createTrack(t, tx, &domain.Track{ID: 1, Source: "A", Target: "B"})
+			addMileage(t, tx, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(1.5)})
+
+			createTrack(t, tx, &domain.Track{ID: 2, Source: "B", Target: "C"})
+			addMileage(t, tx, &domain.Mileage{SignalID: 1, TrackID: 2, Mileage: domain.Miles(2)})
+
+			// Writes are visible inside the transaction.
+			_, err := tx.GetSignal(ctx, 1)
+			require.NoError(t, err, "getting signal inside transaction")
+			return nil
+		})
+		require.NoError(t, err, "committing transaction")
+
+		tracks, count, err := stores.Tracks.ListSignalTracks(ctx, 1, 10, 0)
+		require.NoError(t, err, "listing signal tracks")
+		assert.Equal(t, 2, count, "signal track count")
+		assert.Equal(t, []int{1, 2}, trackIDs(tracks), "signal track ids")
+	})
+
+	t.Run("rollback discards every write", func(t *testing.T) {
+		stores := newStores(t)
+
+		err := stores.Transactor.InTransaction(ctx, func(ctx context.Context, tx domain.Tx) error {
+			createSignal(t, tx, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+			createTrack(t, tx, &domain.Track{ID: 1, Source: "A", Target: "B"})
+			addMileage(t, tx, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(1.5)})
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback, "transaction error")
+
+		_, err = stores.Signals.GetSignal(ctx, 1)
+		require.Error(t, err, "getting rolled back signal")
+		_, err = stores.Tracks.GetTrack(ctx, 1)
+		require.Error(t, err, "getting rolled back track")
+	})
+
+	t.Run("snapshot reads every store", func(t *testing.T) {
+		stores := newStores(t)
+		seedTrackSignal(t, stores, 1, 1, 1.5)
+
+		err := stores.Transactor.InSnapshot(ctx, func(ctx context.Context, tx domain.Tx) error {
+			_, err := tx.GetSignal(ctx, 1)
+			require.NoError(t, err, "getting signal inside snapshot")
+
+			track, err := tx.GetTrackSignals(ctx, 1)
+			require.NoError(t, err, "getting track signals inside snapshot")
+			assert.Len(t, track.Signals, 1, "track signals inside snapshot")
+			return nil
+		})
+		require.NoError(t, err, "reading snapshot")
+	})
+}
+
+func testELRs(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("create, get and update", func(t *testing.T) {
+		stores := newStores(t)
+		elr := &domain.ELR{Code: "ECM1", Description: "East Coast Main Line", EndMileage: domain.Miles(268.5), StartLocation: "Kings Cross", EndLocation: "Shaftholme"}
+
+		createELR(t, stores.ELRs, elr)
+
+		got, err := stores.ELRs.GetELR(ctx, "ECM1")
+		require.NoError(t, err, "getting elr")
+		assert.Equal(t, elr, got, "elr")
+
+		updated := &domain.ELR{Code: "ECM1", Description: "Kings Cross to Shaftholme", EndMileage: domain.Miles(268.5)}
+		require.NoError(t, stores.ELRs.UpdateELR(ctx, updated), "updating elr")
+
+		got, err = stores.ELRs.GetELR(ctx, "ECM1")
+		require.NoError(t, err, "getting elr")
+		assert.Equal(t, updated, got, "updated elr")
+
+		elrs, count, err := stores.ELRs.ListELRs(ctx, 10, 0)
+		require.NoError(t, err, "listing elrs")
+		assert.Equal(t, 2, count, "elr count")
+		assert.Equal(t, []domain.ELR{{Code: "ABC"}, *updated}, elrs, "elrs")
+	})
+
+	t.Run("missing elr", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.ELRs.GetELR(ctx, "ZZZ")
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing elr")
+
+		err = stores.ELRs.UpdateELR(ctx, &domain.ELR{Code: "ZZZ"})
+		require.ErrorIs(t, err, domain.ErrNotFound, "updating missing elr")
+	})
+
+	t.Run("signal on an unregistered elr is rejected", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Signals.CreateSignal(ctx, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ZZZ"}, domain.ConflictFail)
+		var notFound *domain.NotFoundError
+		require.ErrorAs(t, err, &notFound, "creating signal")
+		assert.Equal(t, domain.EntityELR, notFound.Entity, "missing entity")
+	})
+
+	t.Run("delete referenced elr fails", func(t *testing.T) {
+		stores := newStores(t)
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+
+		require.ErrorIs(t, stores.ELRs.DeleteELR(ctx, "ABC"), domain.ErrConflict, "deleting referenced elr")
+
+		require.NoError(t, stores.Signals.DeleteSignal(ctx, 1), "deleting signal")
+		require.NoError(t, stores.ELRs.DeleteELR(ctx, "ABC"), "deleting elr")
+		require.ErrorIs(t, stores.ELRs.DeleteELR(ctx, "ABC"), domain.ErrNotFound, "deleting missing elr")
+
+		_, err := stores.ELRs.GetELR(ctx, "ABC")
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting deleted elr")
+	})
+
+	t.Run("signals in mileage order", func(t *testing.T) {
+		stores := newStores(t)
+		createELR(t, stores.ELRs, &domain.ELR{Code: "XYZ"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 2, Name: "SIG2", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 3, Name: "SIG3", ELR: "XYZ"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 4, Name: "SIG4", ELR: "ABC"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "B", Target: "C"})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(2)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 2, TrackID: 1, Mileage: domain.Miles(1)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 2, TrackID: 2, Mileage: domain.Miles(3)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 3, TrackID: 2, Mileage: domain.Miles(0.5)})
+
+		signals, count, err := stores.ELRs.ListELRSignals(ctx, "ABC", 0, 0)
+		require.NoError(t, err, "listing elr signals")
+		assert.Equal(t, 3, count, "elr signal count")
+		assert.Equal(t, []domain.ELRSignal{
+			{TrackID: 1, TrackSignal: domain.TrackSignal{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(1)}},
+			{TrackID: 1, TrackSignal: domain.TrackSignal{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)}},
+			{TrackID: 2, TrackSignal: domain.TrackSignal{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(3)}},
+		}, signals, "elr signals")
+
+		signals, count, err = stores.ELRs.ListELRSignals(ctx, "ABC", 2, 1)
+		require.NoError(t, err, "listing elr signals page")
+		assert.Equal(t, 3, count, "elr signal count")
+		require.Len(t, signals, 1, "elr signals page")
+		assert.Equal(t, 2, signals[0].ID, "elr signals page")
+	})
+
+	t.Run("signals near a mileage", func(t *testing.T) {
+		stores := newStores(t)
+		createELR(t, stores.ELRs, &domain.ELR{Code: "XYZ"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 2, Name: "SIG2", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 3, Name: "SIG3", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 4, Name: "SIG4", ELR: "XYZ"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 1, TrackID: 1, Mileage: domain.Miles(1)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 2, TrackID: 1, Mileage: domain.Miles(2)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 3, TrackID: 1, Mileage: domain.Miles(4)})
+		addMileage(t, stores.Mileages, &domain.Mileage{SignalID: 4, TrackID: 1, Mileage: domain.Miles(2.5)})
+
+		nearby := func(id int, mileage, distance float64, direction domain.Direction) domain.NearbySignal {
+			return domain.NearbySignal{
+				ELRSignal: domain.ELRSignal{TrackID: 1, TrackSignal: domain.TrackSignal{ID: id, Name: fmt.Sprintf("SIG%d", id), ELR: "ABC", Mileage: miles(mileage)}},
+				Distance:  domain.Miles(distance),
+				Direction: direction,
+			}
+		}
+
+		signals, err := stores.ELRs.ListSignalsNear(ctx, "ABC", domain.Miles(2), domain.DirectionDown, 0)
+		require.NoError(t, err, "listing signals down from mileage")
+		assert.Equal(t, []domain.NearbySignal{
+			nearby(2, 2, 0, domain.DirectionDown),
+			nearby(3, 4, 2, domain.DirectionDown),
+		}, signals, "signals down from mileage")
+
+		signals, err = stores.ELRs.ListSignalsNear(ctx, "ABC", domain.Miles(3), domain.DirectionUp, 1)
+		require.NoError(t, err, "listing signals up from mileage")
+		assert.Equal(t, []domain.NearbySignal{nearby(2, 2, 1, domain.DirectionUp)}, signals, "signals up from mileage")
+	})
+}
+
+func testRoutes(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("create, set and list", func(t *testing.T) {
+		stores := newStores(t)
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+		createSignal(t, stores.Signals, &domain.Signal{ID: 2, Name: "SIG2", ELR: "ABC"})
+		route := &domain.Route{ID: 1, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{3, 4}, State: domain.RouteReleased}
+		other := &domain.Route{ID: 2, EntrySignalID: 2, ExitSignalID: 1, TrackIDs: []int{4, 3}, State: domain.RouteReleased}
+
+		require.NoError(t, stores.Routes.CreateRoute(ctx, route), "creating route")
+		require.NoError(t, stores.Routes.CreateRoute(ctx, other), "creating other route")
+		require.ErrorIs(t, stores.Routes.CreateRoute(ctx, route), domain.ErrConflict, "creating existing route")
+
+		got, err := stores.Routes.GetRoute(ctx, 1)
+		require.NoError(t, err, "getting route")
+		assert.Equal(t, route, got, "route")
+
+		require.NoError(t, stores.Routes.SetRouteState(ctx, 2, domain.RouteSet), "setting route")
+
+		routes, count, err := stores.Routes.ListRoutes(ctx, domain.RouteSet, 10, 0)
+		require.NoError(t, err, "listing set routes")
+		assert.Equal(t, 1, count, "set route count")
+		assert.Equal(t, []domain.Route{{ID: 2, EntrySignalID: 2, ExitSignalID: 1, TrackIDs: []int{4, 3}, State: domain.RouteSet}}, routes, "set routes")
+
+		routes, count, err = stores.Routes.ListRoutes(ctx, "", 1, 1)
+		require.NoError(t, err, "listing every route")
+		assert.Equal(t, 2, count, "route count")
+		assert.Len(t, routes, 1, "route page")
+
+		require.NoError(t, stores.Routes.DeleteRoute(ctx, 1), "deleting route")
+		require.ErrorIs(t, stores.Routes.DeleteRoute(ctx, 1), domain.ErrNotFound, "deleting missing route")
+		_, err = stores.Routes.GetRoute(ctx, 1)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting deleted route")
+	})
+
+	t.Run("missing route", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Routes.GetRoute(ctx, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing route")
+
+		err = stores.Routes.SetRouteState(ctx, 404, domain.RouteSet)
+		require.ErrorIs(t, err, domain.ErrNotFound, "setting missing route")
+	})
+
+	t.Run("route signals must exist and stay", func(t *testing.T) {
+		stores := newStores(t)
+		createSignal(t, stores.Signals, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"})
+
+		err := stores.Routes.CreateRoute(ctx, &domain.Route{ID: 1, EntrySignalID: 1, ExitSignalID: 404, TrackIDs: []int{1}, State: domain.RouteReleased})
+		var notFound *domain.NotFoundError
+		require.ErrorAs(t, err, &notFound, "creating route to a missing signal")
+		assert.Equal(t, domain.EntitySignal, notFound.Entity, "missing entity")
+
+		createSignal(t, stores.Signals, &domain.Signal{ID: 2, Name: "SIG2", ELR: "ABC"})
+		require.NoError(t, stores.Routes.CreateRoute(ctx, &domain.Route{ID: 1, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{1}, State: domain.RouteReleased}), "creating route")
+		require.ErrorIs(t, stores.Signals.DeleteSignal(ctx, 2), domain.ErrConflict, "deleting exit signal")
+	})
+}
+
+func testPoints(t *testing.T, newStores Factory) {
+	ctx := context.Background()
+
+	t.Run("create, update and list", func(t *testing.T) {
+		stores := newStores(t)
+		createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "B", Target: "C"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 3, Source: "B", Target: "D"})
+		point := &domain.Point{ID: 1, Location: "B", NormalTrackID: 2, ReverseTrackID: 3, Position: domain.PointNormal}
+
+		require.NoError(t, stores.Points.CreatePoint(ctx, point), "creating point")
+		require.ErrorIs(t, stores.Points.CreatePoint(ctx, point), domain.ErrConflict, "creating existing point")
+		require.NoError(t, stores.Points.CreatePoint(ctx, &domain.Point{ID: 2, Location: "C", NormalTrackID: 1, ReverseTrackID: 2, Position: domain.PointNormal}), "creating other point")
+
+		point.Position = domain.PointReverse
+		require.NoError(t, stores.Points.UpdatePoint(ctx, point), "updating point")
+
+		got, err := stores.Points.GetPoint(ctx, 1)
+		require.NoError(t, err, "getting point")
+		assert.Equal(t, point, got, "point")
+
+		points, count, err := stores.Points.ListPoints(ctx, "B", 10, 0)
+		require.NoError(t, err, "listing points at a location")
+		assert.Equal(t, 1, count, "point count at the location")
+		assert.Equal(t, []domain.Point{*point}, points, "points at the location")
+
+		points, count, err = stores.Points.ListPoints(ctx, "", 1, 1)
+		require.NoError(t, err, "listing every point")
+		assert.Equal(t, 2, count, "point count")
+		assert.Len(t, points, 1, "point page")
+
+		require.NoError(t, stores.Points.DeletePoint(ctx, 2), "deleting point")
+		require.ErrorIs(t, stores.Points.DeletePoint(ctx, 2), domain.ErrNotFound, "deleting missing point")
+		_, err = stores.Points.GetPoint(ctx, 2)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting deleted point")
+	})
+
+	t.Run("missing point", func(t *testing.T) {
+		stores := newStores(t)
+
+		_, err := stores.Points.GetPoint(ctx, 404)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting missing point")
+	})
+
+	t.Run("points go with their tracks", func(t *testing.T) {
+		stores := newStores(t)
+		createTrack(t, stores.Tracks, &domain.Track{ID: 1, Source: "A", Target: "B"})
+		createTrack(t, stores.Tracks, &domain.Track{ID: 2, Source: "A", Target: "C"})
+
+		err := stores.Points.CreatePoint(ctx, &domain.Point{ID: 1, Location: "A", NormalTrackID: 1, ReverseTrackID: 404, Position: domain.PointNormal})
+		var notFound *domain.NotFoundError
+		require.ErrorAs(t, err, &notFound, "creating point onto a missing track")
+		assert.Equal(t, domain.EntityTrack, notFound.Entity, "missing entity")
+
+		require.NoError(t, stores.Points.CreatePoint(ctx, &domain.Point{ID: 1, Location: "A", NormalTrackID: 1, ReverseTrackID: 2, Position: domain.PointNormal}), "creating point")
+		require.NoError(t, stores.Tracks.DeleteTrack(ctx, 2), "deleting track")
+
+		_, err = stores.Points.GetPoint(ctx, 1)
+		require.ErrorIs(t, err, domain.ErrNotFound, "getting point after its track was deleted")
+	})
+}
+
+// seedTrackSignal creates a signal and a track and places the signal on the track.
+func seedTrackSignal(t *testing.T, stores Stores, signalID, trackID int, mileage float64) {
+	t.Helper()
+
+	createSignal(t, stores.Signals, &domain.Signal{ID: signalID, Name: "SIG", ELR: "ABC"})
+	createTrack(t, stores.Tracks, &domain.Track{ID: trackID, Source: "A", Target: "B"})
+	addMileage(t, stores.Mileages, &domain.Mileage{SignalID: signalID, TrackID: trackID, Mileage: domain.Miles(mileage)})
+}
+
+func createSignal(t *testing.T, store domain.SignalStore, signal *domain.Signal) {
+	t.Helper()
+
+	outcome, err := store.CreateSignal(context.Background(), signal, domain.ConflictFail)
+	require.NoError(t, err, "creating signal")
+	require.Equal(t, domain.WriteInserted, outcome, "creating signal")
+}
+
+func createELR(t *testing.T, store domain.ELRStore, elr *domain.ELR) {
+	t.Helper()
+
+	outcome, err := store.CreateELR(context.Background(), elr, domain.ConflictFail)
+	require.NoError(t, err, "creating elr")
+	require.Equal(t, domain.WriteInserted, outcome, "creating elr")
+}
+
+func createTrack(t *testing.T, store domain.TrackStore, track *domain.Track) {
+	t.Helper()
+
+	outcome, err := store.CreateTrack(context.Background(), track, domain.ConflictFail)
+	require.NoError(t, err, "creating track")
+	require.Equal(t, domain.WriteInserted, outcome, "creating track")
+}
+
+func addMileage(t *testing.T, store domain.MileageStore, mileage *domain.Mileage) {
+	t.Helper()
+
+	outcome, err := store.AddMileage(context.Background(), mileage, domain.ConflictFail)
+	require.NoError(t, err, "adding mileage")
+	require.Equal(t, domain.WriteInserted, outcome, "adding mileage")
+}
+
+func signalIDs(signals []domain.Signal) []int {
+	ids := make([]int, 0, len(signals))
+	for _, s := range signals {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func trackIDs(tracks []domain.Track) []int {
+	ids := make([]int, 0, len(tracks))
+	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func miles(v float64) *domain.Distance {
+	d := domain.Miles(v)
+	return &d
+}
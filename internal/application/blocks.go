@@ -0,0 +1,71 @@
+package application
+
+import (
+	"context"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// TrackBlockSections derives the block sections of the track from its signals in the direction of travel.
+// A track with less than two signals has no block sections.
+func (s *Service) TrackBlockSections(ctx context.Context, trackID int, direction domain.Direction) ([]domain.BlockSection, error) {
+	track, err := s.GetTrackSignals(ctx, trackID, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	return blockSections(*track, direction), nil
+}
+
+// ListBlockSections derives the block sections in the down direction of a page of tracks, ordered by track and mileage.
+// The limit and page count tracks, so only the page's tracks are read, and a page may hold fewer sections than tracks.
+// With an ELR only the sections with a signal on that ELR are listed.
+func (s *Service) ListBlockSections(ctx context.Context, elr string, limit, page int) ([]domain.BlockSection, int, error) {
+	if elr != "" {
+		if _, err := s.elrs(ctx).GetELR(ctx, elr); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	tracks, count, err := s.tracks(ctx).ListTrackSignals(ctx, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sections := []domain.BlockSection{}
+	for _, track := range tracks {
+		for _, section := range blockSections(track, domain.DirectionDown) {
+			if elr == "" || section.EntrySignal.ELR == elr || section.ExitSignal.ELR == elr {
+				sections = append(sections, section)
+			}
+		}
+	}
+
+	return sections, nextPage(limit, page, count), nil
+}
+
+// blockSections pairs each signal on the track with the one after it, the signals are in the order of travel.
+func blockSections(track domain.TrackSignals, direction domain.Direction) []domain.BlockSection {
+	sections := []domain.BlockSection{}
+	for i := 1; i < len(track.Signals); i++ {
+		entry, exit := track.Signals[i-1], track.Signals[i]
+
+		section := domain.BlockSection{
+			TrackID:     track.ID,
+			Direction:   direction,
+			EntrySignal: entry,
+			ExitSignal:  exit,
+			Length:      signalDistance(entry, exit),
+		}
+		if entry.Mileage != nil {
+			section.StartMileage = *entry.Mileage
+		}
+		if exit.Mileage != nil {
+			section.EndMileage = *exit.Mileage
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections
+}
@@ -0,0 +1,95 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestBlockSections(t *testing.T) {
+	network := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+			{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(1.5)},
+			{ID: 3, Name: "SIG3", ELR: "XYZ", Mileage: miles(0.25)},
+		}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 4, Name: "SIG4", ELR: "XYZ", Mileage: miles(1)},
+			{ID: 5, Name: "SIG5", ELR: "XYZ", Mileage: miles(2)},
+		}},
+		{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{
+			{ID: 6, Name: "SIG6", ELR: "XYZ", Mileage: miles(3)},
+		}},
+	}
+	section := func(trackID int, direction domain.Direction, entry, exit domain.TrackSignal, length *domain.Distance) domain.BlockSection {
+		return domain.BlockSection{
+			TrackID:      trackID,
+			Direction:    direction,
+			EntrySignal:  entry,
+			ExitSignal:   exit,
+			StartMileage: *entry.Mileage,
+			EndMileage:   *exit.Mileage,
+			Length:       length,
+		}
+	}
+	sig1, sig2, sig3 := network[0].Signals[0], network[0].Signals[1], network[0].Signals[2]
+	sig4, sig5 := network[1].Signals[0], network[1].Signals[1]
+
+	ctx := context.Background()
+	s := newTestService()
+	require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(network), application.DefaultLoadPolicies, nil), "loading network")
+
+	t.Run("track in each direction", func(t *testing.T) {
+		blocks, err := s.TrackBlockSections(ctx, 1, domain.DirectionDown)
+		require.NoError(t, err, "deriving down blocks")
+		assert.Equal(t, []domain.BlockSection{
+			section(1, domain.DirectionDown, sig3, sig1, nil),
+			section(1, domain.DirectionDown, sig1, sig2, miles(0.5)),
+		}, blocks, "down blocks")
+
+		blocks, err = s.TrackBlockSections(ctx, 1, domain.DirectionUp)
+		require.NoError(t, err, "deriving up blocks")
+		assert.Equal(t, []domain.BlockSection{
+			section(1, domain.DirectionUp, sig2, sig1, miles(0.5)),
+			section(1, domain.DirectionUp, sig1, sig3, nil),
+		}, blocks, "up blocks")
+	})
+
+	t.Run("track with one signal", func(t *testing.T) {
+		blocks, err := s.TrackBlockSections(ctx, 3, domain.DirectionDown)
+		require.NoError(t, err, "deriving blocks")
+		assert.Empty(t, blocks, "blocks")
+	})
+
+	t.Run("network filtered by elr", func(t *testing.T) {
+		blocks, nextPage, err := s.ListBlockSections(ctx, "XYZ", 0, 0)
+		require.NoError(t, err, "listing blocks")
+		assert.Equal(t, 0, nextPage, "next page")
+		assert.Equal(t, []domain.BlockSection{
+			section(1, domain.DirectionDown, sig3, sig1, nil),
+			section(2, domain.DirectionDown, sig4, sig5, miles(1)),
+		}, blocks, "blocks")
+
+		blocks, nextPage, err = s.ListBlockSections(ctx, "", 2, 0)
+		require.NoError(t, err, "listing the first page of tracks")
+		assert.Equal(t, 1, nextPage, "next page")
+		assert.Equal(t, []domain.BlockSection{
+			section(1, domain.DirectionDown, sig3, sig1, nil),
+			section(1, domain.DirectionDown, sig1, sig2, miles(0.5)),
+			section(2, domain.DirectionDown, sig4, sig5, miles(1)),
+		}, blocks, "blocks of the first two tracks")
+
+		blocks, nextPage, err = s.ListBlockSections(ctx, "", 2, 1)
+		require.NoError(t, err, "listing the last page of tracks")
+		assert.Equal(t, 0, nextPage, "next page")
+		assert.Empty(t, blocks, "blocks of a track with one signal")
+
+		_, _, err = s.ListBlockSections(ctx, "ZZZ", 0, 0)
+		require.ErrorIs(t, err, domain.ErrNotFound, "listing blocks on a missing elr")
+	})
+}
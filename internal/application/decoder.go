@@ -0,0 +1,262 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// TrackSignalsSource yields TrackSignals records one at a time, returning io.EOF once exhausted.
+type TrackSignalsSource interface {
+	Next() (domain.TrackSignals, error)
+}
+
+// SliceSource yields the records of an in-memory slice.
+func SliceSource(trackSignals []domain.TrackSignals) TrackSignalsSource {
+	return &sliceSource{trackSignals: trackSignals}
+}
+
+type sliceSource struct {
+	trackSignals []domain.TrackSignals
+}
+
+func (s *sliceSource) Next() (domain.TrackSignals, error) {
+	if len(s.trackSignals) == 0 {
+		return domain.TrackSignals{}, io.EOF
+	}
+
+	ts := s.trackSignals[0]
+	s.trackSignals = s.trackSignals[1:]
+
+	return ts, nil
+}
+
+// SignalSource is implemented by sources that also carry signals that aren't on any track,
+// so they can be stored along with the records.
+type SignalSource interface {
+	Signals() ([]domain.Signal, error)
+}
+
+// ELRSource is implemented by sources that carry the ELRs their records are measured along,
+// so they can be registered before the records are loaded.
+type ELRSource interface {
+	ELRs() ([]domain.ELR, error)
+}
+
+// TrackSignalsDecoder streams a JSON array of TrackSignals records.
+// Only the record being decoded is held in memory, whatever the size of the input.
+//
+// The input may instead be an object in the export format, with the "elrs" the records use and the
+// "signals" that aren't on any track ahead of the "tracks" array. The ELRs and signals are read up front
+// and returned by ELRs and Signals.
+//
+// The bare NaN, Infinity and -Infinity tokens written by pandas are read as null.
+type TrackSignalsDecoder struct {
+	// Unit is the unit mileages given as bare numbers are read in, decimal miles when empty.
+	Unit domain.Unit
+
+	dec *json.Decoder
+	// index is the array index of the next record.
+	index   int
+	started bool
+	// inObject is set when the tracks array is held in an export object.
+	inObject bool
+	elrs     []domain.ELR
+	signals  []domain.Signal
+	// startErr is kept so a failure to read the start of the input is returned every time.
+	startErr error
+}
+
+// NewTrackSignalsDecoder returns a decoder reading from r.
+func NewTrackSignalsDecoder(r io.Reader) *TrackSignalsDecoder {
+	return &TrackSignalsDecoder{dec: json.NewDecoder(newNonFiniteReader(r))}
+}
+
+// ELRs returns the ELRs given ahead of the tracks, none when the input is a bare array.
+func (d *TrackSignalsDecoder) ELRs() ([]domain.ELR, error) {
+	if err := d.start(); err != nil {
+		return nil, err
+	}
+
+	return d.elrs, nil
+}
+
+// Signals returns the signals given ahead of the tracks, none when the input is a bare array.
+func (d *TrackSignalsDecoder) Signals() ([]domain.Signal, error) {
+	if err := d.start(); err != nil {
+		return nil, err
+	}
+
+	return d.signals, nil
+}
+
+// Next decodes the next record in the array, returning io.EOF after the last one.
+func (d *TrackSignalsDecoder) Next() (domain.TrackSignals, error) {
+	if err := d.start(); err != nil {
+		return domain.TrackSignals{}, err
+	}
+
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return domain.TrackSignals{}, fmt.Errorf("reading end of array: %w", err)
+		}
+		if d.inObject {
+			if tok, err := d.dec.Token(); err != nil || tok != json.Delim('}') {
+				return domain.TrackSignals{}, errors.New("expected the end of the object after the tracks")
+			}
+		}
+		return domain.TrackSignals{}, io.EOF
+	}
+
+	var record json.RawMessage
+	if err := d.dec.Decode(&record); err != nil {
+		return domain.TrackSignals{}, fmt.Errorf("decoding track at index %d: %w", d.index, err)
+	}
+	var ts domain.TrackSignals
+	if err := UnmarshalJSONIn(record, &ts, d.Unit); err != nil {
+		return domain.TrackSignals{}, fmt.Errorf("decoding track at index %d: %w", d.index, err)
+	}
+	d.index++
+
+	return ts, nil
+}
+
+// start reads up to the first record, along with the ELRs and signals when the input is an export object.
+func (d *TrackSignalsDecoder) start() error {
+	if d.started {
+		return d.startErr
+	}
+	d.started = true
+
+	tok, err := d.dec.Token()
+	switch {
+	case err != nil:
+		d.startErr = fmt.Errorf("reading start of array: %w", err)
+	case tok == json.Delim('{'):
+		d.inObject = true
+		d.startErr = d.readExportHeader()
+	case tok != json.Delim('['):
+		d.startErr = errors.New("expected a JSON array of tracks")
+	}
+
+	return d.startErr
+}
+
+// readExportHeader reads the fields of an export object up to the start of its tracks array.
+func (d *TrackSignalsDecoder) readExportHeader() error {
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return fmt.Errorf("reading export: %w", err)
+		}
+
+		switch tok {
+		case "elrs":
+			var elrs json.RawMessage
+			if err := d.dec.Decode(&elrs); err != nil {
+				return fmt.Errorf("decoding elrs: %w", err)
+			}
+			if err := UnmarshalJSONIn(elrs, &d.elrs, d.Unit); err != nil {
+				return fmt.Errorf("decoding elrs: %w", err)
+			}
+		case "signals":
+			if err := d.dec.Decode(&d.signals); err != nil {
+				return fmt.Errorf("decoding signals: %w", err)
+			}
+		case "tracks":
+			if tok, err := d.dec.Token(); err != nil || tok != json.Delim('[') {
+				return errors.New("expected tracks to be a JSON array")
+			}
+			return nil
+		default:
+			return fmt.Errorf("expected a JSON array of tracks, or an export with elrs and signals followed by tracks, found %v", tok)
+		}
+	}
+}
+
+var (
+	nanToken         = []byte("NaN")
+	infinityToken    = []byte("Infinity")
+	negInfinityToken = []byte("-Infinity")
+	nullToken        = []byte("null")
+)
+
+// nonFiniteReader rewrites the bare NaN, Infinity and -Infinity tokens to null.
+// It tracks whether it is inside a JSON string so that text such as a signal named "NaN" is left alone.
+type nonFiniteReader struct {
+	r *bufio.Reader
+	// pending holds output that did not fit in the last read.
+	pending []byte
+
+	inString bool
+	escaped  bool
+}
+
+func newNonFiniteReader(r io.Reader) *nonFiniteReader {
+	return &nonFiniteReader{r: bufio.NewReader(r)}
+}
+
+func (n *nonFiniteReader) Read(p []byte) (int, error) {
+	written := copy(p, n.pending)
+	n.pending = n.pending[written:]
+
+	for written < len(p) {
+		b, err := n.r.ReadByte()
+		if err != nil {
+			if written > 0 {
+				return written, nil
+			}
+			return 0, err
+		}
+
+		var token []byte
+		switch {
+		case n.inString:
+			switch {
+			case n.escaped:
+				n.escaped = false
+			case b == '\\':
+				n.escaped = true
+			case b == '"':
+				n.inString = false
+			}
+		case b == '"':
+			n.inString = true
+		case b == nanToken[0]:
+			token = nanToken
+		case b == infinityToken[0]:
+			token = infinityToken
+		case b == negInfinityToken[0]:
+			token = negInfinityToken
+		}
+
+		if token == nil || !n.skipToken(token) {
+			p[written] = b
+			written++
+			continue
+		}
+
+		c := copy(p[written:], nullToken)
+		written += c
+		n.pending = append(n.pending, nullToken[c:]...)
+	}
+
+	return written, nil
+}
+
+// skipToken reports whether the byte just read and the bytes following it spell out token, and if so skips them.
+// Otherwise nothing is skipped and the byte is left for the JSON decoder to deal with.
+func (n *nonFiniteReader) skipToken(token []byte) bool {
+	rest, err := n.r.Peek(len(token) - 1)
+	if err != nil || !bytes.Equal(rest, token[1:]) {
+		return false
+	}
+
+	n.r.Discard(len(rest))
+	return true
+}
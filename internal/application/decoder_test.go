@@ -0,0 +1,126 @@
+package application_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestTrackSignalsDecoder(t *testing.T) {
+	tests := map[string]struct {
+		input string
+
+		want          []domain.TrackSignals
+		wantELRs      []domain.ELR
+		wantSignals   []domain.Signal
+		errorContains string
+	}{
+		"non finite mileages are read as null": {
+			input: `[{"track_id": 1, "source": "A", "target": "B", "signal_ids": [
+				{"signal_id": 1, "signal_name": "SIG1", "elr": "ABC", "mileage": NaN},
+				{"signal_id": 2, "signal_name": "SIG2", "elr": "ABC", "mileage": Infinity},
+				{"signal_id": 3, "signal_name": "SIG3", "elr": "ABC", "mileage":-Infinity},
+				{"signal_id": 4, "signal_name": "SIG4", "elr": "ABC", "mileage": -1.5}
+			]}]`,
+			want: []domain.TrackSignals{{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "SIG1", ELR: "ABC"},
+				{ID: 2, Name: "SIG2", ELR: "ABC"},
+				{ID: 3, Name: "SIG3", ELR: "ABC"},
+				{ID: 4, Name: "SIG4", ELR: "ABC", Mileage: miles(-1.5)},
+			}}},
+		},
+		"NaN inside strings is left alone": {
+			input: `[{"track_id": 1, "source": "NaNTWICH", "target": "Infinity \"NaN\"", "signal_ids": [
+				{"signal_id": 1, "signal_name": "NaN", "elr": "NAN", "mileage": 2}
+			]}]`,
+			want: []domain.TrackSignals{{ID: 1, Source: "NaNTWICH", Target: `Infinity "NaN"`, Signals: []domain.TrackSignal{
+				{ID: 1, Name: "NaN", ELR: "NAN", Mileage: miles(2)},
+			}}},
+		},
+		"multiple records": {
+			input: `[{"track_id": 1, "source": "A", "target": "B"}, {"track_id": 2, "source": "B", "target": "C"}]`,
+			want: []domain.TrackSignals{
+				{ID: 1, Source: "A", Target: "B"},
+				{ID: 2, Source: "B", Target: "C"},
+			},
+		},
+		"empty array": {
+			input: `[]`,
+		},
+		"not an array": {
+			input:         `{"track_id": 1}`,
+			errorContains: "expected a JSON array",
+		},
+		"export with elrs": {
+			input:    `{"elrs": [{"code": "ABC", "end_mileage": 20}], "tracks": [{"track_id": 1, "source": "A", "target": "B"}]}`,
+			want:     []domain.TrackSignals{{ID: 1, Source: "A", Target: "B"}},
+			wantELRs: []domain.ELR{{Code: "ABC", EndMileage: domain.Miles(20)}},
+		},
+		"export with signals on no track": {
+			input: `{"signals": [{"id": 2, "signal_name": "SIG2", "elr": "ABC"}],
+				"tracks": [{"track_id": 1, "source": "A", "target": "B"}]}`,
+			want:        []domain.TrackSignals{{ID: 1, Source: "A", Target: "B"}},
+			wantSignals: []domain.Signal{{ID: 2, Name: "SIG2", ELR: "ABC"}},
+		},
+		"export without tracks": {
+			input:         `{"signals": []}`,
+			errorContains: "expected a JSON array of tracks",
+		},
+		"export with content after the tracks": {
+			input:         `{"tracks": [], "signals": []}`,
+			errorContains: "expected the end of the object",
+		},
+		"invalid record reports its index": {
+			input:         `[{"track_id": 1}, {"track_id": "two"}]`,
+			errorContains: "index 1",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Reading a byte at a time checks tokens split across reads are still recognised.
+			dec := application.NewTrackSignalsDecoder(iotest.OneByteReader(strings.NewReader(test.input)))
+
+			elrs, err := dec.ELRs()
+			if test.errorContains != "" && err != nil {
+				require.ErrorContains(t, err, test.errorContains, "decode error contains")
+				return
+			}
+			require.NoError(t, err, "decoding ELRs")
+			assert.Equal(t, test.wantELRs, elrs, "decoded ELRs")
+
+			signals, err := dec.Signals()
+			if test.errorContains != "" && err != nil {
+				require.ErrorContains(t, err, test.errorContains, "decode error contains")
+				return
+			}
+			require.NoError(t, err, "decoding signals")
+			assert.Equal(t, test.wantSignals, signals, "decoded signals")
+
+			var got []domain.TrackSignals
+			for {
+				ts, err := dec.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if test.errorContains != "" && err != nil {
+					require.ErrorContains(t, err, test.errorContains, "decode error contains")
+					return
+				}
+				require.NoError(t, err, "decoding track signals")
+				got = append(got, ts)
+			}
+
+			require.Empty(t, test.errorContains, "expected a decode error")
+			assert.Equal(t, test.want, got, "decoded track signals")
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// dataset holds tracks, signals and mileages keyed for comparison.
+type dataset struct {
+	// elrs are registered when the dataset is applied, they are never removed.
+	elrs     []domain.ELR
+	tracks   map[int]domain.Track
+	signals  map[int]domain.Signal
+	mileages map[mileageKey]domain.Mileage
+	// firstTrack and firstSignal are the index of the record each track and signal was first read from.
+	firstTrack  map[int]int
+	firstSignal map[int]int
+}
+
+type mileageKey struct {
+	signalID int
+	trackID  int
+}
+
+func newDataset() *dataset {
+	return &dataset{
+		tracks:   make(map[int]domain.Track),
+		signals:  make(map[int]domain.Signal),
+		mileages: make(map[mileageKey]domain.Mileage),
+
+		firstTrack:  make(map[int]int),
+		firstSignal: make(map[int]int),
+	}
+}
+
+// add adds the record at index, returning an issue for each way it contradicts an earlier record.
+// The whole dataset is held for the comparison anyway, so unlike a load every record is checked against the others.
+func (d *dataset) add(index int, ts domain.TrackSignals) []domain.LoadIssue {
+	var issues []domain.LoadIssue
+	if first, ok := d.firstTrack[ts.ID]; ok {
+		issues = append(issues, domain.LoadIssue{Index: index, TrackID: ts.ID, Field: "track_id", Reason: fmt.Sprintf("duplicates the track at index %d", first)})
+	} else {
+		d.firstTrack[ts.ID] = index
+	}
+	d.tracks[ts.ID] = domain.Track{ID: ts.ID, Source: ts.Source, Target: ts.Target}
+
+	for i, signal := range ts.Signals {
+		wanted := domain.Signal{ID: signal.ID, Name: signal.Name, ELR: signal.ELR, SignalKind: signal.SignalKind}
+		if first, ok := d.firstSignal[signal.ID]; !ok {
+			d.firstSignal[signal.ID] = index
+		} else if first != index && d.signals[signal.ID] != wanted {
+			issues = append(issues, domain.LoadIssue{
+				Index: index, TrackID: ts.ID, SignalID: signal.ID,
+				Field:  fmt.Sprintf("signal_ids[%d].signal_id", i),
+				Reason: fmt.Sprintf("differs from the same signal at index %d", first),
+			})
+			continue
+		}
+		d.signals[signal.ID] = wanted
+		if signal.Mileage != nil {
+			key := mileageKey{signalID: signal.ID, trackID: ts.ID}
+			d.mileages[key] = domain.Mileage{SignalID: signal.ID, TrackID: ts.ID, Mileage: *signal.Mileage}
+		}
+	}
+
+	return issues
+}
+
+// DiffTrackSignals compares the records from the source with everything in the store.
+// With apply the store is changed to match the source exactly, anything missing from the source is deleted.
+// The source is read and validated in full before the store is touched.
+func (s *Service) DiffTrackSignals(ctx context.Context, source TrackSignalsSource, apply bool) (*domain.DatasetDiff, error) {
+	wanted, err := s.readDataset(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if !apply {
+		stored, err := s.storedDataset(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return diffDatasets(stored, wanted), nil
+	}
+
+	var diff *domain.DatasetDiff
+	err = s.inTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.storedDataset(ctx)
+		if err != nil {
+			return err
+		}
+
+		diff = diffDatasets(stored, wanted)
+		return s.applyDiff(ctx, wanted, diff)
+	})
+	if err != nil {
+		return nil, err
+	}
+	diff.Applied = true
+
+	s.Logger.WithContext(ctx).WithField("empty", diff.Empty()).Info("Applied dataset diff")
+
+	return diff, nil
+}
+
+// readDataset reads every record from the source, returning a LoadValidationError if any are invalid.
+func (s *Service) readDataset(ctx context.Context, source TrackSignalsSource) (*dataset, error) {
+	elrs, issues, err := sourceELRs(source)
+	if err != nil {
+		return nil, &LoadValidationError{Issues: []domain.LoadIssue{{Reason: err.Error()}}}
+	}
+
+	validator := s.newLoadValidator()
+	validator.registered(elrs)
+	validationErr := &LoadValidationError{}
+	validationErr.add(issues...)
+	wanted := newDataset()
+	wanted.elrs = elrs
+
+	signals, issues, err := sourceSignals(source)
+	if err != nil {
+		validationErr.add(domain.LoadIssue{Reason: err.Error()})
+		return nil, validationErr
+	}
+	validationErr.add(issues...)
+	unregistered, err := validator.unregisteredSignals(ctx, signals)
+	if err != nil {
+		return nil, err
+	}
+	validationErr.add(unregistered...)
+	for _, signal := range signals {
+		wanted.signals[signal.ID] = signal
+	}
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ts, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The rest of the input can't be read once the JSON is malformed.
+			validationErr.add(domain.LoadIssue{Index: index, Reason: err.Error()})
+			break
+		}
+
+		issues, err := validator.validate(ctx, index, ts)
+		if err != nil {
+			return nil, err
+		}
+		validationErr.add(issues...)
+		validationErr.add(wanted.add(index, ts)...)
+	}
+
+	if len(validationErr.Issues) > 0 {
+		return nil, validationErr
+	}
+
+	return wanted, nil
+}
+
+// storedDataset reads every track, signal and mileage from the store.
+func (s *Service) storedDataset(ctx context.Context) (*dataset, error) {
+	stored := newDataset()
+
+	tracks, err := s.allTrackSignals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The store holds each track and signal once, so its records can't contradict each other.
+	for i, ts := range tracks {
+		stored.add(i, ts)
+	}
+
+	// Signals that aren't on any track are only found by listing the signals.
+	for page := 0; ; page++ {
+		signals, count, err := s.signals(ctx).ListSignals(ctx, domain.SignalFilter{}, networkPageSize, page)
+		if err != nil {
+			return nil, fmt.Errorf("listing signals: %w", err)
+		}
+		for _, signal := range signals {
+			stored.signals[signal.ID] = signal
+		}
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+
+	return stored, nil
+}
+
+func diffDatasets(stored, wanted *dataset) *domain.DatasetDiff {
+	return &domain.DatasetDiff{
+		Tracks: diffEntities(stored.tracks, wanted.tracks, func(id int) domain.Change {
+			return domain.Change{TrackID: id}
+		}, trackFields),
+		Signals: diffEntities(stored.signals, wanted.signals, func(id int) domain.Change {
+			return domain.Change{SignalID: id}
+		}, signalFields),
+		Mileages: diffEntities(stored.mileages, wanted.mileages, func(key mileageKey) domain.Change {
+			return domain.Change{TrackID: key.trackID, SignalID: key.signalID}
+		}, mileageFields),
+	}
+}
+
+// field is a named value of an entity, in the order they are reported.
+// Unset optional fields are left out of added and removed entities.
+type field struct {
+	name  string
+	value any
+	unset bool
+}
+
+func trackFields(t domain.Track) []field {
+	return []field{{"source", t.Source, false}, {"target", t.Target, false}}
+}
+
+func signalFields(s domain.Signal) []field {
+	return []field{
+		{"signal_name", s.Name, false},
+		{"elr", s.ELR, false},
+		{"type", s.Type, s.Type == ""},
+		{"aspects", s.Aspects, s.Aspects == 0},
+		{"route_indicator", s.RouteIndicator, s.RouteIndicator == ""},
+		{"automatic", s.Automatic, !s.Automatic},
+	}
+}
+
+func mileageFields(m domain.Mileage) []field {
+	return []field{{"mileage", m.Mileage, false}}
+}
+
+// diffEntities compares the stored entities with the wanted ones, changes are ordered by track and then signal.
+func diffEntities[K, V comparable](stored, wanted map[K]V, change func(K) domain.Change, fields func(V) []field) domain.ChangeSet {
+	set := domain.ChangeSet{Added: []domain.Change{}, Modified: []domain.Change{}, Removed: []domain.Change{}}
+
+	for key, after := range wanted {
+		before, ok := stored[key]
+		switch {
+		case !ok:
+			c := change(key)
+			for _, f := range fields(after) {
+				if !f.unset {
+					c.Fields = append(c.Fields, domain.FieldChange{Field: f.name, After: f.value})
+				}
+			}
+			set.Added = append(set.Added, c)
+		case before != after:
+			c := change(key)
+			beforeFields, afterFields := fields(before), fields(after)
+			for i := range afterFields {
+				if beforeFields[i].value != afterFields[i].value {
+					c.Fields = append(c.Fields, domain.FieldChange{
+						Field:  afterFields[i].name,
+						Before: beforeFields[i].value,
+						After:  afterFields[i].value,
+					})
+				}
+			}
+			set.Modified = append(set.Modified, c)
+		}
+	}
+
+	for key, before := range stored {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		c := change(key)
+		for _, f := range fields(before) {
+			if !f.unset {
+				c.Fields = append(c.Fields, domain.FieldChange{Field: f.name, Before: f.value})
+			}
+		}
+		set.Removed = append(set.Removed, c)
+	}
+
+	for _, changes := range [][]domain.Change{set.Added, set.Modified, set.Removed} {
+		slices.SortFunc(changes, func(a, b domain.Change) int {
+			return cmp.Or(cmp.Compare(a.TrackID, b.TrackID), cmp.Compare(a.SignalID, b.SignalID))
+		})
+	}
+
+	return set
+}
+
+// applyDiff writes the changes to the store. Mileages are removed first so that the signals they
+// reference can be deleted, and added last once their tracks and signals exist.
+func (s *Service) applyDiff(ctx context.Context, wanted *dataset, diff *domain.DatasetDiff) error {
+	if err := s.checkInterlockingKept(ctx, diff); err != nil {
+		return err
+	}
+	if err := s.registerELRs(ctx, wanted.elrs); err != nil {
+		return err
+	}
+
+	for _, c := range diff.Mileages.Removed {
+		if err := s.mileages(ctx).DeleteMileage(ctx, c.SignalID, c.TrackID); err != nil {
+			return fmt.Errorf("removing mileage of signal %d on track %d: %w", c.SignalID, c.TrackID, err)
+		}
+	}
+	for _, c := range diff.Tracks.Removed {
+		if err := s.tracks(ctx).DeleteTrack(ctx, c.TrackID); err != nil {
+			return fmt.Errorf("removing track %d: %w", c.TrackID, err)
+		}
+	}
+	for _, c := range diff.Signals.Removed {
+		if err := s.signals(ctx).DeleteSignal(ctx, c.SignalID); err != nil {
+			return fmt.Errorf("removing signal %d: %w", c.SignalID, err)
+		}
+	}
+
+	for _, c := range slices.Concat(diff.Tracks.Added, diff.Tracks.Modified) {
+		track := wanted.tracks[c.TrackID]
+		if _, err := s.tracks(ctx).CreateTrack(ctx, &track, domain.ConflictUpsert); err != nil {
+			return fmt.Errorf("writing track %d: %w", c.TrackID, err)
+		}
+	}
+	for _, c := range slices.Concat(diff.Signals.Added, diff.Signals.Modified) {
+		signal := wanted.signals[c.SignalID]
+		if _, err := s.signals(ctx).CreateSignal(ctx, &signal, domain.ConflictUpsert); err != nil {
+			return fmt.Errorf("writing signal %d: %w", c.SignalID, err)
+		}
+	}
+	for _, c := range slices.Concat(diff.Mileages.Added, diff.Mileages.Modified) {
+		mileage := wanted.mileages[mileageKey{signalID: c.SignalID, trackID: c.TrackID}]
+		if _, err := s.mileages(ctx).AddMileage(ctx, &mileage, domain.ConflictUpsert); err != nil {
+			return fmt.Errorf("writing mileage of signal %d on track %d: %w", c.SignalID, c.TrackID, err)
+		}
+	}
+
+	return nil
+}
+
+// checkInterlockingKept fails when a route or point uses a track or signal the diff removes.
+// The interlocking isn't part of the file, so it has to be changed before the tracks and signals can go.
+func (s *Service) checkInterlockingKept(ctx context.Context, diff *domain.DatasetDiff) error {
+	if len(diff.Tracks.Removed) == 0 && len(diff.Signals.Removed) == 0 {
+		return nil
+	}
+
+	removedTracks := make(map[int]bool, len(diff.Tracks.Removed))
+	for _, c := range diff.Tracks.Removed {
+		removedTracks[c.TrackID] = true
+	}
+	removedSignals := make(map[int]bool, len(diff.Signals.Removed))
+	for _, c := range diff.Signals.Removed {
+		removedSignals[c.SignalID] = true
+	}
+
+	if err := s.routes(ctx).LockRoutes(ctx); err != nil {
+		return err
+	}
+	routes, _, err := s.routes(ctx).ListRoutes(ctx, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	points, _, err := s.points(ctx).ListPoints(ctx, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	var trackUses, signalUses []string
+	for _, point := range points {
+		for _, trackID := range []int{point.NormalTrackID, point.ReverseTrackID} {
+			if removedTracks[trackID] {
+				trackUses = append(trackUses, fmt.Sprintf("track %d is switched by point %d", trackID, point.ID))
+			}
+		}
+	}
+	for _, route := range routes {
+		for _, trackID := range route.TrackIDs {
+			if removedTracks[trackID] {
+				trackUses = append(trackUses, fmt.Sprintf("track %d is on route %d", trackID, route.ID))
+			}
+		}
+		for _, signalID := range []int{route.EntrySignalID, route.ExitSignalID} {
+			if removedSignals[signalID] {
+				signalUses = append(signalUses, fmt.Sprintf("signal %d begins or ends route %d", signalID, route.ID))
+			}
+		}
+	}
+
+	entity := domain.EntityTrack
+	if len(trackUses) == 0 {
+		if len(signalUses) == 0 {
+			return nil
+		}
+		entity = domain.EntitySignal
+	}
+
+	return &domain.ConflictError{Entity: entity, Reason: "the interlocking still uses what the file removes: " + strings.Join(slices.Concat(trackUses, signalUses), ", ")}
+}
@@ -0,0 +1,194 @@
+package application_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestDiffTrackSignals(t *testing.T) {
+	stored := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+			{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+		}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: miles(3)},
+		}},
+	}
+
+	tests := map[string]struct {
+		input domain.TrackSignalSlice
+
+		want       domain.DatasetDiff
+		wantIssues []domain.LoadIssue
+	}{
+		"identical file": {
+			input: stored,
+			want: domain.DatasetDiff{
+				Tracks:   emptyChangeSet(),
+				Signals:  emptyChangeSet(),
+				Mileages: emptyChangeSet(),
+			},
+		},
+		"added, modified and removed": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "Z", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "RENAMED", ELR: "ABC", Mileage: miles(1.5)},
+				}},
+				{ID: 3, Source: "Z", Target: "D", Signals: []domain.TrackSignal{
+					{ID: 4, Name: "SIG4", ELR: "XYZ", Mileage: miles(4)},
+				}},
+			},
+			want: domain.DatasetDiff{
+				Tracks: domain.ChangeSet{
+					Added: []domain.Change{{TrackID: 3, Fields: []domain.FieldChange{
+						{Field: "source", After: "Z"},
+						{Field: "target", After: "D"},
+					}}},
+					Modified: []domain.Change{{TrackID: 1, Fields: []domain.FieldChange{
+						{Field: "target", Before: "B", After: "Z"},
+					}}},
+					Removed: []domain.Change{{TrackID: 2, Fields: []domain.FieldChange{
+						{Field: "source", Before: "B"},
+						{Field: "target", Before: "C"},
+					}}},
+				},
+				Signals: domain.ChangeSet{
+					Added: []domain.Change{{SignalID: 4, Fields: []domain.FieldChange{
+						{Field: "signal_name", After: "SIG4"},
+						{Field: "elr", After: "XYZ"},
+					}}},
+					Modified: []domain.Change{{SignalID: 1, Fields: []domain.FieldChange{
+						{Field: "signal_name", Before: "SIG1", After: "RENAMED"},
+					}}},
+					Removed: []domain.Change{
+						{SignalID: 2, Fields: []domain.FieldChange{{Field: "signal_name", Before: "SIG2"}, {Field: "elr", Before: "ABC"}}},
+						{SignalID: 3, Fields: []domain.FieldChange{{Field: "signal_name", Before: "SIG3"}, {Field: "elr", Before: "ABC"}}},
+					},
+				},
+				Mileages: domain.ChangeSet{
+					Added: []domain.Change{{TrackID: 3, SignalID: 4, Fields: []domain.FieldChange{
+						{Field: "mileage", After: domain.Miles(4)},
+					}}},
+					Modified: []domain.Change{{TrackID: 1, SignalID: 1, Fields: []domain.FieldChange{
+						{Field: "mileage", Before: domain.Miles(1), After: domain.Miles(1.5)},
+					}}},
+					Removed: []domain.Change{
+						{TrackID: 1, SignalID: 2, Fields: []domain.FieldChange{{Field: "mileage", Before: domain.Miles(2)}}},
+						{TrackID: 2, SignalID: 3, Fields: []domain.FieldChange{{Field: "mileage", Before: domain.Miles(3)}}},
+					},
+				},
+			},
+		},
+		"invalid file": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A"},
+			},
+			wantIssues: []domain.LoadIssue{{Index: 0, TrackID: 1, Field: "target", Reason: "is required"}},
+		},
+		"records that contradict each other": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 1, Name: "RENAMED", ELR: "ABC", Mileage: miles(2)}}},
+				{ID: 1, Source: "A", Target: "B"},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 1, TrackID: 2, SignalID: 1, Field: "signal_ids[0].signal_id", Reason: "differs from the same signal at index 0"},
+				{Index: 2, TrackID: 1, Field: "track_id", Reason: "duplicates the track at index 0"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			for _, ts := range stored {
+				_, err := s.CreateTrackSignals(ctx, &ts, domain.ConflictPolicies{})
+				require.NoError(t, err, "creating stored track")
+			}
+
+			diff, err := s.DiffTrackSignals(ctx, application.SliceSource(test.input), false)
+			if test.wantIssues != nil {
+				var validationErr *application.LoadValidationError
+				require.ErrorAs(t, err, &validationErr, "load validation error")
+				assert.Equal(t, test.wantIssues, validationErr.Issues, "load issues")
+				return
+			}
+			require.NoError(t, err, "diffing track signals")
+			assert.Equal(t, &test.want, diff, "dataset diff")
+
+			applied, err := s.DiffTrackSignals(ctx, application.SliceSource(test.input), true)
+			require.NoError(t, err, "applying dataset diff")
+			assert.True(t, applied.Applied, "diff applied")
+
+			again, err := s.DiffTrackSignals(ctx, application.SliceSource(test.input), false)
+			require.NoError(t, err, "diffing applied track signals")
+			assert.True(t, again.Empty(), "store matches the file after applying")
+		})
+	}
+}
+
+func TestApplyDiffKeepsInterlocking(t *testing.T) {
+	tests := map[string]struct {
+		points []domain.Point
+		routes []domain.Route
+		input  domain.TrackSignalSlice
+
+		wantEntity domain.Entity
+		wantReason string
+	}{
+		"removing a track that has a point": {
+			points:     []domain.Point{interlockingPoint},
+			input:      slices.Delete(slices.Clone(interlockingNetwork), 2, 3),
+			wantEntity: domain.EntityTrack,
+			wantReason: "the interlocking still uses what the file removes: track 3 is switched by point 1",
+		},
+		"removing a signal used by a route": {
+			routes: []domain.Route{interlockingRoutes[6]},
+			input: domain.TrackSignalSlice{
+				interlockingNetwork[0],
+				interlockingNetwork[1],
+				interlockingNetwork[2],
+				{ID: 4, Source: "C", Target: "E"},
+			},
+			wantEntity: domain.EntitySignal,
+			wantReason: "the interlocking still uses what the file removes: signal 6 begins or ends route 7",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(interlockingNetwork), application.DefaultLoadPolicies, nil), "loading network")
+			for _, point := range test.points {
+				require.NoError(t, s.CreatePoint(ctx, &point), "creating point %d", point.ID)
+			}
+			for _, route := range test.routes {
+				require.NoError(t, s.CreateRoute(ctx, &route), "creating route %d", route.ID)
+			}
+
+			_, err := s.DiffTrackSignals(ctx, application.SliceSource(test.input), true)
+			var conflictErr *domain.ConflictError
+			require.ErrorAs(t, err, &conflictErr, "applying dataset diff")
+			assert.Equal(t, test.wantEntity, conflictErr.Entity, "conflict entity")
+			assert.Equal(t, test.wantReason, conflictErr.Reason, "conflict reason")
+
+			unchanged, err := s.DiffTrackSignals(ctx, application.SliceSource(interlockingNetwork), false)
+			require.NoError(t, err, "diffing the stored network")
+			assert.True(t, unchanged.Empty(), "nothing is removed")
+		})
+	}
+}
+
+func emptyChangeSet() domain.ChangeSet {
+	return domain.ChangeSet{Added: []domain.Change{}, Modified: []domain.Change{}, Removed: []domain.Change{}}
+}
@@ -0,0 +1,154 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateELR registers the ELR, the policy decides what happens when it is already registered.
+func (s *Service) CreateELR(ctx context.Context, elr *domain.ELR, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	var v validation
+	v.elr(*elr)
+	if err := v.err(); err != nil {
+		return "", err
+	}
+
+	return s.elrs(ctx).CreateELR(ctx, elr, policy)
+}
+
+func (s *Service) GetELR(ctx context.Context, code string) (*domain.ELR, error) {
+	return s.elrs(ctx).GetELR(ctx, code)
+}
+
+func (s *Service) ListELRs(ctx context.Context, limit, page int) ([]domain.ELR, int, error) {
+	elrs, count, err := s.elrs(ctx).ListELRs(ctx, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return elrs, nextPage(limit, page, count), nil
+}
+
+// UpdateELR replaces the ELR with the code from the path.
+// A missing code in the body is taken from the path, a different one is a validation error.
+func (s *Service) UpdateELR(ctx context.Context, code string, elr *domain.ELR) error {
+	var v validation
+	matchPathKey(&v, "code", code, &elr.Code)
+	v.elr(*elr)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	return s.elrs(ctx).UpdateELR(ctx, elr)
+}
+
+// DeleteELR removes the ELR from the registry, it fails while signals still reference it.
+func (s *Service) DeleteELR(ctx context.Context, code string) error {
+	return s.elrs(ctx).DeleteELR(ctx, code)
+}
+
+// ListELRSignals lists the signals on the ELR in mileage order, a signal on several tracks is listed once per track.
+func (s *Service) ListELRSignals(ctx context.Context, code string, limit, page int) ([]domain.ELRSignal, int, error) {
+	if _, err := s.elrs(ctx).GetELR(ctx, code); err != nil {
+		return nil, 0, err
+	}
+
+	signals, count, err := s.elrs(ctx).ListELRSignals(ctx, code, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return signals, nextPage(limit, page, count), nil
+}
+
+// NearestSignals finds the signals on the ELR nearest the mileage, looking in the direction or both ways when it is
+// empty. The signals are ordered by their distance from the mileage and at most limit are returned.
+func (s *Service) NearestSignals(ctx context.Context, code string, mileage domain.Distance, direction domain.Direction, limit int) ([]domain.NearbySignal, error) {
+	var v validation
+	v.check("elr", elrProblem(code))
+	v.check("mileage", mileageProblem(mileage))
+	if limit < 1 {
+		v.check("limit", "must be positive")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.elrs(ctx).GetELR(ctx, code); err != nil {
+		return nil, err
+	}
+
+	directions := []domain.Direction{direction}
+	if direction == "" {
+		directions = []domain.Direction{domain.DirectionDown, domain.DirectionUp}
+	}
+
+	signals := []domain.NearbySignal{}
+	for _, d := range directions {
+		found, err := s.elrs(ctx).ListSignalsNear(ctx, code, mileage, d, limit)
+		if err != nil {
+			return nil, err
+		}
+		signals = append(signals, found...)
+	}
+
+	for i := range signals {
+		if signals[i].Distance == 0 {
+			signals[i].Direction = ""
+		}
+	}
+	slices.SortStableFunc(signals, func(a, b domain.NearbySignal) int {
+		return cmp.Or(
+			cmp.Compare(a.Distance, b.Distance),
+			cmp.Compare(a.TrackID, b.TrackID),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	// A signal at the mileage is found looking both ways.
+	signals = slices.CompactFunc(signals, func(a, b domain.NearbySignal) bool {
+		return a.TrackID == b.TrackID && a.ID == b.ID
+	})
+
+	if len(signals) > limit {
+		signals = signals[:limit]
+	}
+	return signals, nil
+}
+
+// checkRegistered reports an ELR that isn't in the registry as a problem with the field.
+// The registered codes are remembered in known so each code is only looked up once.
+// Malformed codes are left to the field checks.
+func (s *Service) checkRegistered(ctx context.Context, v *validation, known map[string]bool, field, code string) error {
+	if elrProblem(code) != "" {
+		return nil
+	}
+
+	registered, err := s.isRegistered(ctx, known, code)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		v.check(field, "is not registered")
+	}
+
+	return nil
+}
+
+// isRegistered reports whether the ELR is in the registry, remembering the answer in known.
+func (s *Service) isRegistered(ctx context.Context, known map[string]bool, code string) (bool, error) {
+	if registered, ok := known[code]; ok {
+		return registered, nil
+	}
+
+	_, err := s.elrs(ctx).GetELR(ctx, code)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return false, err
+	}
+	known[code] = err == nil
+
+	return known[code], nil
+}
@@ -0,0 +1,80 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestNearestSignals(t *testing.T) {
+	tests := map[string]struct {
+		mileage   domain.Distance
+		direction domain.Direction
+		limit     int
+
+		wantSignals    []int
+		wantDirections []domain.Direction
+		wantErr        error
+	}{
+		"both ways nearest first": {
+			mileage:        domain.Miles(2.25),
+			limit:          3,
+			wantSignals:    []int{2, 3, 1},
+			wantDirections: []domain.Direction{domain.DirectionUp, domain.DirectionDown, domain.DirectionUp},
+		},
+		"signal at the mileage is listed once": {
+			mileage:        domain.Miles(2),
+			limit:          10,
+			wantSignals:    []int{2, 1, 3},
+			wantDirections: []domain.Direction{"", domain.DirectionUp, domain.DirectionDown},
+		},
+		"one direction": {
+			mileage:        domain.Miles(2.25),
+			direction:      domain.DirectionUp,
+			limit:          10,
+			wantSignals:    []int{2, 1},
+			wantDirections: []domain.Direction{domain.DirectionUp, domain.DirectionUp},
+		},
+		"limit must be positive": {
+			limit:   0,
+			wantErr: domain.ErrValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			err := s.LoadTrackSignals(ctx, application.SliceSource(domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+					{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: miles(3)},
+					{ID: 4, Name: "SIG4", ELR: "XYZ", Mileage: miles(2.25)},
+				}},
+			}), application.DefaultLoadPolicies, nil)
+			require.NoError(t, err, "loading signals")
+
+			signals, err := s.NearestSignals(ctx, "ABC", test.mileage, test.direction, test.limit)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "finding nearest signals")
+				return
+			}
+			require.NoError(t, err, "finding nearest signals")
+
+			var ids []int
+			var directions []domain.Direction
+			for _, signal := range signals {
+				ids = append(ids, signal.ID)
+				directions = append(directions, signal.Direction)
+			}
+			assert.Equal(t, test.wantSignals, ids, "nearest signals")
+			assert.Equal(t, test.wantDirections, directions, "directions to the signals")
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// ExportTrackSignals writes the ELR registry and every track with its signals to w, in the export format read by
+// NewTrackSignalsDecoder: an object with the "elrs" and the "signals" that aren't on any track followed by the
+// "tracks" array. The ELRs come first so they can be registered before the signals that use them.
+// Everything is read from one snapshot of the store a page at a time and written one record per line,
+// so the export is consistent and memory use does not grow with the size of the network.
+// Mileages are written in the unit.
+func (s *Service) ExportTrackSignals(ctx context.Context, w io.Writer, unit domain.Unit) error {
+	return s.inSnapshot(ctx, func(ctx context.Context) error {
+		return s.exportTrackSignals(ctx, w, unit)
+	})
+}
+
+func (s *Service) exportTrackSignals(ctx context.Context, w io.Writer, unit domain.Unit) error {
+	flusher, _ := w.(interface{ Flush() })
+
+	first := true
+	for page := 0; ; page++ {
+		elrs, count, err := s.elrs(ctx).ListELRs(ctx, networkPageSize, page)
+		if err != nil {
+			return fmt.Errorf("listing ELRs: %w", err)
+		}
+
+		// Nothing is written until the first page is read so a failing store can still be reported.
+		if page == 0 {
+			if _, err := io.WriteString(w, `{"elrs":[`+"\n"); err != nil {
+				return err
+			}
+		}
+
+		for _, elr := range elrs {
+			if err := writeExportRecord(w, elr, unit, first); err != nil {
+				return fmt.Errorf("encoding ELR %s: %w", elr.Code, err)
+			}
+			first = false
+		}
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+	if _, err := io.WriteString(w, `],"signals":[`+"\n"); err != nil {
+		return err
+	}
+
+	first = true
+	for page := 0; ; page++ {
+		signals, count, err := s.signals(ctx).ListUnplacedSignals(ctx, networkPageSize, page)
+		if err != nil {
+			return fmt.Errorf("listing unplaced signals: %w", err)
+		}
+
+		for _, signal := range signals {
+			if err := writeExportRecord(w, signal, unit, first); err != nil {
+				return fmt.Errorf("encoding signal %d: %w", signal.ID, err)
+			}
+			first = false
+		}
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+	if _, err := io.WriteString(w, `],"tracks":[`+"\n"); err != nil {
+		return err
+	}
+
+	first = true
+	for page := 0; ; page++ {
+		tracks, count, err := s.tracks(ctx).ListTrackSignals(ctx, networkPageSize, page)
+		if err != nil {
+			return fmt.Errorf("listing track signals: %w", err)
+		}
+
+		for _, ts := range tracks {
+			if ts.Signals == nil {
+				ts.Signals = []domain.TrackSignal{}
+			}
+			if err := writeExportRecord(w, ts, unit, first); err != nil {
+				return fmt.Errorf("encoding track %d: %w", ts.ID, err)
+			}
+			first = false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// writeExportRecord writes a record of the export on its own line, after a comma unless it is the first.
+func writeExportRecord(w io.Writer, record any, unit domain.Unit, first bool) error {
+	if !first {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+
+	data, err := MarshalJSONIn(record, unit)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
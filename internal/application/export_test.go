@@ -0,0 +1,154 @@
+package application_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// exportELR is the ELR the exported signals are on.
+var exportELR = domain.ELR{Code: "ABC", Description: "Test line", EndMileage: domain.Miles(20), StartLocation: "A", EndLocation: "C"}
+
+func TestExportTrackSignalsRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		elrs   []domain.ELR
+		tracks domain.TrackSignalSlice
+		// signals are created on no track.
+		signals []domain.Signal
+		unit    domain.Unit
+
+		wantExport string
+	}{
+		"empty network": {
+			wantExport: "{\"elrs\":[\n],\"signals\":[\n],\"tracks\":[\n]}\n",
+		},
+		"tracks with and without signals": {
+			elrs: []domain.ELR{exportELR},
+			tracks: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2.5)},
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+				}},
+				{ID: 2, Source: "B", Target: "C"},
+			},
+			wantExport: `{"elrs":[
+{"code":"ABC","description":"Test line","start_mileage":0,"end_mileage":20,"start_location":"A","end_location":"C"}
+],"signals":[
+],"tracks":[
+{"track_id":1,"source":"A","target":"B","signal_ids":[{"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":1},{"signal_id":2,"signal_name":"SIG2","elr":"ABC","mileage":2.5}]}
+,{"track_id":2,"source":"B","target":"C","signal_ids":[]}
+]}
+`,
+		},
+		"signals on no track": {
+			elrs: []domain.ELR{exportELR, {Code: "XYZ"}},
+			tracks: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+			},
+			signals: []domain.Signal{{ID: 3, Name: "SIG3", ELR: "ABC"}, {ID: 2, Name: "SIG2", ELR: "XYZ"}},
+			wantExport: `{"elrs":[
+{"code":"ABC","description":"Test line","start_mileage":0,"end_mileage":20,"start_location":"A","end_location":"C"}
+,{"code":"XYZ","description":"","start_mileage":0,"end_mileage":0,"start_location":"","end_location":""}
+],"signals":[
+{"id":2,"signal_name":"SIG2","elr":"XYZ"}
+,{"id":3,"signal_name":"SIG3","elr":"ABC"}
+],"tracks":[
+{"track_id":1,"source":"A","target":"B","signal_ids":[{"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":1}]}
+]}
+`,
+		},
+		"signal kinds": {
+			elrs: []domain.ELR{exportELR},
+			tracks: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1), SignalKind: domain.SignalKind{
+						Type: domain.SignalMain, Aspects: 4, RouteIndicator: domain.RouteIndicatorJunction, Automatic: true,
+					}},
+				}},
+			},
+			wantExport: `{"elrs":[
+{"code":"ABC","description":"Test line","start_mileage":0,"end_mileage":20,"start_location":"A","end_location":"C"}
+],"signals":[
+],"tracks":[
+{"track_id":1,"source":"A","target":"B","signal_ids":[{"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":1,"type":"main","aspects":4,"route_indicator":"junction","automatic":true}]}
+]}
+`,
+		},
+		"miles and chains": {
+			elrs: []domain.ELR{exportELR},
+			tracks: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(12.425)},
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(12.5)},
+				}},
+			},
+			unit: domain.UnitMilesChains,
+			wantExport: `{"elrs":[
+{"code":"ABC","description":"Test line","start_mileage":"0m 0ch","end_mileage":"20m 0ch","start_location":"A","end_location":"C"}
+],"signals":[
+],"tracks":[
+{"track_id":1,"source":"A","target":"B","signal_ids":[{"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":"12m 34ch"},{"signal_id":2,"signal_name":"SIG2","elr":"ABC","mileage":"12m 40ch"}]}
+]}
+`,
+		},
+		"kilometres": {
+			elrs: []domain.ELR{exportELR},
+			tracks: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+				}},
+			},
+			unit: domain.UnitKilometres,
+			wantExport: `{"elrs":[
+{"code":"ABC","description":"Test line","start_mileage":0,"end_mileage":32.18688,"start_location":"A","end_location":"C"}
+],"signals":[
+],"tracks":[
+{"track_id":1,"source":"A","target":"B","signal_ids":[{"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":1.609344}]}
+]}
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			original := newEmptyService()
+			for _, elr := range test.elrs {
+				_, err := original.CreateELR(ctx, &elr, domain.ConflictFail)
+				require.NoError(t, err, "registering ELR %s", elr.Code)
+			}
+			require.NoError(t, original.LoadTrackSignals(ctx, application.SliceSource(test.tracks), application.DefaultLoadPolicies, nil), "loading original")
+			for _, signal := range test.signals {
+				_, err := original.CreateSignal(ctx, &signal, domain.ConflictFail)
+				require.NoError(t, err, "creating signal on no track")
+			}
+
+			var export bytes.Buffer
+			require.NoError(t, original.ExportTrackSignals(ctx, &export, test.unit), "exporting original")
+			assert.Equal(t, test.wantExport, export.String(), "export")
+
+			clone := newEmptyService()
+			dec := application.NewTrackSignalsDecoder(bytes.NewReader(export.Bytes()))
+			dec.Unit = test.unit
+			err := clone.LoadTrackSignals(ctx, dec, application.DefaultLoadPolicies, nil)
+			require.NoError(t, err, "loading export into an empty store")
+
+			dec = application.NewTrackSignalsDecoder(bytes.NewReader(export.Bytes()))
+			dec.Unit = test.unit
+			diff, err := clone.DiffTrackSignals(ctx, dec, false)
+			require.NoError(t, err, "diffing export against the clone")
+			assert.True(t, diff.Empty(), "clone matches the export")
+
+			var cloneExport bytes.Buffer
+			require.NoError(t, clone.ExportTrackSignals(ctx, &cloneExport, test.unit), "exporting clone")
+			assert.Equal(t, export.String(), cloneExport.String(), "clone export")
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreateRoute stores a new route in the released state.
+// The tracks must be joined end to end in the order given, with the entry signal on the first and the exit signal on the last.
+func (s *Service) CreateRoute(ctx context.Context, route *domain.Route) error {
+	var v validation
+	v.route(*route)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	if _, err := s.routeSpans(ctx, *route); err != nil {
+		return err
+	}
+
+	route.State = domain.RouteReleased
+	return s.routes(ctx).CreateRoute(ctx, route)
+}
+
+func (s *Service) GetRoute(ctx context.Context, routeID int) (*domain.Route, error) {
+	return s.routes(ctx).GetRoute(ctx, routeID)
+}
+
+// ListRoutes lists the routes in the state, every route when the state is empty.
+func (s *Service) ListRoutes(ctx context.Context, state domain.RouteState, limit, page int) ([]domain.Route, int, error) {
+	routes, count, err := s.routes(ctx).ListRoutes(ctx, state, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return routes, nextPage(limit, page, count), nil
+}
+
+// DeleteRoute removes a route, it fails while the route is set.
+func (s *Service) DeleteRoute(ctx context.Context, routeID int) error {
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.routes(ctx).LockRoutes(ctx); err != nil {
+			return err
+		}
+
+		route, err := s.routes(ctx).GetRoute(ctx, routeID)
+		if err != nil {
+			return err
+		}
+		if route.State == domain.RouteSet {
+			return &domain.ConflictError{Entity: domain.EntityRoute, Reason: fmt.Sprintf("route %d is set, it must be released first", routeID)}
+		}
+
+		return s.routes(ctx).DeleteRoute(ctx, routeID)
+	})
+}
+
+// SetRoute sets the route for a train. It is refused when the route is already set, when the points it runs through
+// aren't set for it, or when a route that is set runs over any of the same line, whether in the same direction
+// or opposing it. Routes may meet at a signal.
+// Routes are locked while the request is checked so concurrent requests can't set conflicting routes.
+func (s *Service) SetRoute(ctx context.Context, routeID int) (*domain.Route, error) {
+	var route *domain.Route
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.routes(ctx).LockRoutes(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		route, err = s.routes(ctx).GetRoute(ctx, routeID)
+		if err != nil {
+			return err
+		}
+		if route.State == domain.RouteSet {
+			return &domain.ConflictError{Entity: domain.EntityRoute, Reason: fmt.Sprintf("route %d is already set", routeID)}
+		}
+
+		spans, err := s.routeSpans(ctx, *route)
+		if err != nil {
+			return err
+		}
+		if err := s.checkRoutePoints(ctx, *route, spans); err != nil {
+			return err
+		}
+
+		set, _, err := s.routes(ctx).ListRoutes(ctx, domain.RouteSet, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, other := range set {
+			if err := s.checkRouteConflict(ctx, *route, spans, other); err != nil {
+				return err
+			}
+		}
+
+		route.State = domain.RouteSet
+		return s.routes(ctx).SetRouteState(ctx, routeID, domain.RouteSet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
+
+// ReleaseRoute releases a set route so the line it holds can be used by other routes.
+func (s *Service) ReleaseRoute(ctx context.Context, routeID int) (*domain.Route, error) {
+	var route *domain.Route
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.routes(ctx).LockRoutes(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		route, err = s.routes(ctx).GetRoute(ctx, routeID)
+		if err != nil {
+			return err
+		}
+		if route.State != domain.RouteSet {
+			return &domain.ConflictError{Entity: domain.EntityRoute, Reason: fmt.Sprintf("route %d is not set", routeID)}
+		}
+
+		route.State = domain.RouteReleased
+		return s.routes(ctx).SetRouteState(ctx, routeID, domain.RouteReleased)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return route, nil
+}
+
+// routeSpan is the part of a track a route runs over and the direction it runs in.
+// The ends are positions on the track in the down direction: -1 is the up end, the signals are
+// numbered from 0 in mileage order and the number of signals is the down end.
+type routeSpan struct {
+	trackID   int
+	direction domain.Direction
+	from, to  int
+	// enters and leaves are the locations the route runs onto and off the track at,
+	// empty where it starts or ends at a signal on the track.
+	enters, leaves string
+}
+
+// overlaps reports whether the spans share any line, spans that only meet at a signal don't.
+func (a routeSpan) overlaps(b routeSpan) bool {
+	return a.trackID == b.trackID && a.from < b.to && b.from < a.to
+}
+
+// checkRouteConflict returns a conflict when the route would run over line held by the other route, which is set.
+func (s *Service) checkRouteConflict(ctx context.Context, route domain.Route, spans []routeSpan, other domain.Route) error {
+	otherSpans, err := s.routeSpans(ctx, other)
+	if err != nil {
+		// The layout has changed under the set route, it holds the whole of each of its tracks until it is released.
+		otherSpans = make([]routeSpan, 0, len(other.TrackIDs))
+		for _, trackID := range other.TrackIDs {
+			otherSpans = append(otherSpans, routeSpan{trackID: trackID, from: -1, to: math.MaxInt})
+		}
+	}
+
+	for _, span := range spans {
+		for _, otherSpan := range otherSpans {
+			if !span.overlaps(otherSpan) {
+				continue
+			}
+
+			reason := fmt.Sprintf("route %d conflicts with route %d over track %d", route.ID, other.ID, span.trackID)
+			if otherSpan.direction != "" && otherSpan.direction != span.direction {
+				reason = fmt.Sprintf("route %d opposes route %d over track %d", route.ID, other.ID, span.trackID)
+			}
+			return &domain.ConflictError{Entity: domain.EntityRoute, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// checkRoutePoints returns a conflict when the points where the route runs from one track onto the next aren't set for it.
+func (s *Service) checkRoutePoints(ctx context.Context, route domain.Route, spans []routeSpan) error {
+	for i := 1; i < len(spans); i++ {
+		location := spans[i].enters
+		points, _, err := s.points(ctx).ListPoints(ctx, location, 0, 0)
+		if err != nil {
+			return err
+		}
+
+		if !NewNetwork(nil, points).canPass(location, spans[i-1].trackID, spans[i].trackID) {
+			return &domain.ConflictError{Entity: domain.EntityRoute, Reason: fmt.Sprintf(
+				"route %d can't run from track %d onto track %d, the points at %s are not set for it",
+				route.ID, spans[i-1].trackID, spans[i].trackID, location)}
+		}
+	}
+
+	return nil
+}
+
+// checkPointFree returns a conflict when a set route runs through any of the locations of the point,
+// the points there can't change under the route until it is released. The caller must lock the routes.
+func (s *Service) checkPointFree(ctx context.Context, pointID int, locations ...string) error {
+	set, _, err := s.routes(ctx).ListRoutes(ctx, domain.RouteSet, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range set {
+		through, err := s.routeLocations(ctx, route)
+		if err != nil {
+			return err
+		}
+		for _, location := range locations {
+			if through[location] {
+				return &domain.ConflictError{Entity: domain.EntityPoint, Reason: fmt.Sprintf(
+					"point %d at %s is held by route %d, the route must be released first", pointID, location, route.ID)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// routeLocations returns the locations the route runs through from one track onto the next.
+func (s *Service) routeLocations(ctx context.Context, route domain.Route) (map[string]bool, error) {
+	locations := make(map[string]bool)
+
+	spans, err := s.routeSpans(ctx, route)
+	if err != nil {
+		// The layout has changed under the set route, it holds both ends of each of its tracks until it is released.
+		for _, trackID := range route.TrackIDs {
+			track, err := s.tracks(ctx).GetTrack(ctx, trackID)
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			locations[track.Source] = true
+			locations[track.Target] = true
+		}
+		return locations, nil
+	}
+
+	for _, span := range spans {
+		if span.leaves != "" {
+			locations[span.leaves] = true
+		}
+	}
+	return locations, nil
+}
+
+// routeSpans works out the line the route runs over on each of its tracks.
+// A route over one track runs the way from its entry signal to its exit signal. Over several tracks it runs
+// the way that takes it from each track onto the next, through the location they share.
+func (s *Service) routeSpans(ctx context.Context, route domain.Route) ([]routeSpan, error) {
+	tracks := make([]*domain.TrackSignals, 0, len(route.TrackIDs))
+	for _, trackID := range route.TrackIDs {
+		track, err := s.GetTrackSignals(ctx, trackID, domain.DirectionDown)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	position := func(track *domain.TrackSignals, signalID int) int {
+		return slices.IndexFunc(track.Signals, func(signal domain.TrackSignal) bool { return signal.ID == signalID })
+	}
+	first, last := tracks[0], tracks[len(tracks)-1]
+	entry, exit := position(first, route.EntrySignalID), position(last, route.ExitSignalID)
+
+	var v validation
+	if entry < 0 {
+		v.check("entry_signal_id", fmt.Sprintf("is not on track %d, the first track of the route", first.ID))
+	}
+	if exit < 0 {
+		v.check("exit_signal_id", fmt.Sprintf("is not on track %d, the last track of the route", last.ID))
+	}
+
+	directions := make([]domain.Direction, len(tracks))
+	if len(tracks) == 1 {
+		directions[0] = domain.DirectionDown
+		if exit < entry {
+			directions[0] = domain.DirectionUp
+		}
+	} else {
+		directions[0] = domain.DirectionUp
+		if next := tracks[1]; first.Target == next.Source || first.Target == next.Target {
+			directions[0] = domain.DirectionDown
+		}
+		// A track that isn't joined on leaves the direction of the rest unknown, only the first gap is reported.
+		for i := 1; i < len(tracks) && directions[i-1] != ""; i++ {
+			joint := tracks[i-1].Target
+			if directions[i-1] == domain.DirectionUp {
+				joint = tracks[i-1].Source
+			}
+
+			switch joint {
+			case tracks[i].Source:
+				directions[i] = domain.DirectionDown
+			case tracks[i].Target:
+				directions[i] = domain.DirectionUp
+			default:
+				v.check(fmt.Sprintf("track_ids[%d]", i), fmt.Sprintf("is not joined to track %d at %s", tracks[i-1].ID, joint))
+			}
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	spans := make([]routeSpan, len(tracks))
+	for i, track := range tracks {
+		// Start and end are the positions the route runs between, in its direction of travel.
+		start, end := -1, len(track.Signals)
+		if directions[i] == domain.DirectionUp {
+			start, end = end, start
+		}
+		if i == 0 {
+			start = entry
+		}
+		if i == len(tracks)-1 {
+			end = exit
+		}
+
+		span := routeSpan{trackID: track.ID, direction: directions[i], from: min(start, end), to: max(start, end)}
+		if i > 0 {
+			span.enters = track.Source
+			if directions[i] == domain.DirectionUp {
+				span.enters = track.Target
+			}
+		}
+		if i < len(tracks)-1 {
+			span.leaves = track.Target
+			if directions[i] == domain.DirectionUp {
+				span.leaves = track.Source
+			}
+		}
+		spans[i] = span
+	}
+
+	return spans, nil
+}
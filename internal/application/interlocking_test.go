@@ -0,0 +1,282 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// interlockingNetwork is a line from A through B and C to E, with a branch from D that meets it at C.
+var interlockingNetwork = domain.TrackSignalSlice{
+	{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+		{ID: 1, ELR: "ABC", Mileage: miles(1)},
+		{ID: 2, ELR: "ABC", Mileage: miles(2)},
+	}},
+	{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+		{ID: 3, ELR: "ABC", Mileage: miles(3)},
+		{ID: 4, ELR: "ABC", Mileage: miles(4)},
+	}},
+	{ID: 3, Source: "D", Target: "C", Signals: []domain.TrackSignal{
+		{ID: 5, ELR: "XYZ", Mileage: miles(1)},
+	}},
+	{ID: 4, Source: "C", Target: "E", Signals: []domain.TrackSignal{
+		{ID: 6, ELR: "ABC", Mileage: miles(5)},
+	}},
+}
+
+// interlockingPoint selects the line from B or the branch from D onto the track to E at C.
+var interlockingPoint = domain.Point{ID: 1, Location: "C", NormalTrackID: 2, ReverseTrackID: 3}
+
+// interlockingRoutes are the routes over interlockingNetwork.
+var interlockingRoutes = []domain.Route{
+	{ID: 1, EntrySignalID: 1, ExitSignalID: 4, TrackIDs: []int{1, 2}},
+	{ID: 2, EntrySignalID: 4, ExitSignalID: 1, TrackIDs: []int{2, 1}},
+	{ID: 3, EntrySignalID: 2, ExitSignalID: 3, TrackIDs: []int{1, 2}},
+	{ID: 4, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{1}},
+	{ID: 5, EntrySignalID: 2, ExitSignalID: 4, TrackIDs: []int{1, 2}},
+	{ID: 6, EntrySignalID: 4, ExitSignalID: 5, TrackIDs: []int{2, 3}},
+	{ID: 7, EntrySignalID: 4, ExitSignalID: 6, TrackIDs: []int{2, 4}},
+}
+
+func newInterlockingService(t *testing.T) *application.Service {
+	t.Helper()
+
+	ctx := context.Background()
+	s := newTestService()
+	require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(interlockingNetwork), application.DefaultLoadPolicies, nil), "loading network")
+	for _, route := range interlockingRoutes {
+		require.NoError(t, s.CreateRoute(ctx, &route), "creating route %d", route.ID)
+	}
+
+	return s
+}
+
+func TestCreateRoute(t *testing.T) {
+	tests := map[string]struct {
+		route domain.Route
+
+		wantFields []string
+		wantErr    error
+	}{
+		"route over one track": {
+			route: domain.Route{ID: 10, EntrySignalID: 4, ExitSignalID: 3, TrackIDs: []int{2}},
+		},
+		"route onto a track that runs the other way": {
+			route: domain.Route{ID: 10, EntrySignalID: 5, ExitSignalID: 3, TrackIDs: []int{3, 2}},
+		},
+		"missing fields": {
+			route:      domain.Route{},
+			wantFields: []string{"id", "entry_signal_id", "exit_signal_id", "track_ids"},
+			wantErr:    domain.ErrValidation,
+		},
+		"track repeated": {
+			route:      domain.Route{ID: 10, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{1, 1}},
+			wantFields: []string{"track_ids[1]"},
+			wantErr:    domain.ErrValidation,
+		},
+		"tracks not joined": {
+			route:      domain.Route{ID: 10, EntrySignalID: 1, ExitSignalID: 5, TrackIDs: []int{1, 3}},
+			wantFields: []string{"track_ids[1]"},
+			wantErr:    domain.ErrValidation,
+		},
+		"signals off the route": {
+			route:      domain.Route{ID: 10, EntrySignalID: 3, ExitSignalID: 1, TrackIDs: []int{1, 2}},
+			wantFields: []string{"entry_signal_id", "exit_signal_id"},
+			wantErr:    domain.ErrValidation,
+		},
+		"missing track": {
+			route:   domain.Route{ID: 10, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{404}},
+			wantErr: domain.ErrNotFound,
+		},
+		"existing route": {
+			route:   domain.Route{ID: 1, EntrySignalID: 1, ExitSignalID: 2, TrackIDs: []int{1}},
+			wantErr: domain.ErrConflict,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newInterlockingService(t)
+
+			err := s.CreateRoute(ctx, &test.route)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "creating route")
+
+				var validationErr *domain.ValidationError
+				if errors.As(err, &validationErr) {
+					fields := make([]string, 0, len(validationErr.Fields))
+					for _, field := range validationErr.Fields {
+						fields = append(fields, field.Field)
+					}
+					assert.Equal(t, test.wantFields, fields, "invalid fields")
+				}
+				return
+			}
+			require.NoError(t, err, "creating route")
+
+			got, err := s.GetRoute(ctx, test.route.ID)
+			require.NoError(t, err, "getting route")
+			assert.Equal(t, domain.RouteReleased, got.State, "state of a new route")
+		})
+	}
+}
+
+func TestSetRoute(t *testing.T) {
+	tests := map[string]struct {
+		points []domain.Point
+		set    []int
+		route  int
+
+		wantErr    error
+		wantReason string
+	}{
+		"nothing else set": {
+			route: 1,
+		},
+		"opposing route": {
+			set: []int{1}, route: 2,
+			wantErr:    domain.ErrConflict,
+			wantReason: "route 2 opposes route 1 over track 2",
+		},
+		"route over the same line": {
+			set: []int{1}, route: 3,
+			wantErr:    domain.ErrConflict,
+			wantReason: "route 3 conflicts with route 1 over track 1",
+		},
+		"routes meeting at a signal": {
+			set: []int{4}, route: 5,
+		},
+		"route onward from the exit signal of a set route": {
+			set: []int{1}, route: 6,
+		},
+		"route already set": {
+			set: []int{1}, route: 1,
+			wantErr: domain.ErrConflict,
+		},
+		"points set for the route": {
+			points: []domain.Point{interlockingPoint},
+			route:  7,
+		},
+		"points not set for the route": {
+			points:     []domain.Point{{ID: 1, Location: "C", NormalTrackID: 2, ReverseTrackID: 3, Position: domain.PointReverse}},
+			route:      7,
+			wantErr:    domain.ErrConflict,
+			wantReason: "route 7 can't run from track 2 onto track 4, the points at C are not set for it",
+		},
+		"missing route": {
+			route:   404,
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newInterlockingService(t)
+			for _, point := range test.points {
+				require.NoError(t, s.CreatePoint(ctx, &point), "creating point %d", point.ID)
+			}
+			for _, id := range test.set {
+				_, err := s.SetRoute(ctx, id)
+				require.NoError(t, err, "setting route %d", id)
+			}
+
+			route, err := s.SetRoute(ctx, test.route)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "setting route")
+
+				var conflict *domain.ConflictError
+				if test.wantReason != "" && assert.ErrorAs(t, err, &conflict, "setting route") {
+					assert.Equal(t, test.wantReason, conflict.Reason, "conflict reason")
+				}
+				return
+			}
+			require.NoError(t, err, "setting route")
+			assert.Equal(t, domain.RouteSet, route.State, "state")
+
+			set, _, err := s.ListRoutes(ctx, domain.RouteSet, 0, 0)
+			require.NoError(t, err, "listing set routes")
+			assert.Len(t, set, len(test.set)+1, "set routes")
+		})
+	}
+}
+
+func TestReleaseRoute(t *testing.T) {
+	ctx := context.Background()
+	s := newInterlockingService(t)
+
+	_, err := s.ReleaseRoute(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrConflict, "releasing a route that isn't set")
+
+	_, err = s.SetRoute(ctx, 1)
+	require.NoError(t, err, "setting route")
+	require.ErrorIs(t, s.DeleteRoute(ctx, 1), domain.ErrConflict, "deleting a set route")
+
+	route, err := s.ReleaseRoute(ctx, 1)
+	require.NoError(t, err, "releasing route")
+	assert.Equal(t, domain.RouteReleased, route.State, "state")
+
+	_, err = s.SetRoute(ctx, 2)
+	require.NoError(t, err, "setting the opposing route once the route is released")
+}
+
+func TestPointHeldBySetRoute(t *testing.T) {
+	ctx := context.Background()
+	s := newInterlockingService(t)
+	point := interlockingPoint
+	require.NoError(t, s.CreatePoint(ctx, &point), "creating point")
+	_, err := s.SetRoute(ctx, 7)
+	require.NoError(t, err, "setting route")
+
+	switched := interlockingPoint
+	switched.Position = domain.PointReverse
+	require.ErrorIs(t, s.UpdatePoint(ctx, switched.ID, &switched), domain.ErrConflict, "switching a point held by a set route")
+	require.ErrorIs(t, s.DeletePoint(ctx, switched.ID), domain.ErrConflict, "deleting a point held by a set route")
+
+	_, err = s.ReleaseRoute(ctx, 7)
+	require.NoError(t, err, "releasing route")
+	require.NoError(t, s.UpdatePoint(ctx, switched.ID, &switched), "switching the point once the route is released")
+}
+
+func TestSetRouteConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s := newInterlockingService(t)
+
+	// Routes 1, 2 and 3 all conflict with each other, only one of them can be set.
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		set []int
+	)
+	for range 10 {
+		for _, id := range []int{1, 2, 3} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, err := s.SetRoute(ctx, id)
+				if err == nil {
+					mu.Lock()
+					set = append(set, id)
+					mu.Unlock()
+					return
+				}
+				assert.ErrorIs(t, err, domain.ErrConflict, "setting route %d", id)
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Len(t, set, 1, "routes set")
+	routes, _, err := s.ListRoutes(ctx, domain.RouteSet, 0, 0)
+	require.NoError(t, err, "listing set routes")
+	assert.Len(t, routes, 1, "set routes")
+}
@@ -0,0 +1,302 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// ErrLoadQueueFull is returned when too many load jobs are waiting to run.
+var ErrLoadQueueFull = errors.New("load queue is full")
+
+// loadQueueSize is the number of load jobs that can wait for the worker.
+const loadQueueSize = 64
+
+const (
+	// DefaultFinishedJobTTL is how long a finished load job can still be read.
+	DefaultFinishedJobTTL = 24 * time.Hour
+	// DefaultMaxFinishedJobs is the number of finished load jobs kept, the oldest are dropped first.
+	DefaultMaxFinishedJobs = 1000
+)
+
+// LoadJobs runs uploaded files through LoadTrackSignals in the background, one job at a time.
+// Job records are kept in memory, finished jobs are dropped once they are older than FinishedJobTTL
+// or there are more than MaxFinishedJobs of them.
+type LoadJobs struct {
+	// FinishedJobTTL and MaxFinishedJobs bound the finished jobs kept, they default to
+	// DefaultFinishedJobTTL and DefaultMaxFinishedJobs.
+	FinishedJobTTL  time.Duration
+	MaxFinishedJobs int
+
+	service *Service
+	// dir is where uploads are stored until their job finishes.
+	dir string
+
+	mu    sync.Mutex
+	jobs  map[string]*loadJob
+	queue chan string
+}
+
+type loadJob struct {
+	job  domain.LoadJob
+	path string
+
+	// cancel stops the job while it is running.
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// NewLoadJobs creates the job queue, uploads are stored in dir.
+// Run must be called for the jobs to be processed.
+func NewLoadJobs(s *Service, dir string) *LoadJobs {
+	return &LoadJobs{
+		FinishedJobTTL:  DefaultFinishedJobTTL,
+		MaxFinishedJobs: DefaultMaxFinishedJobs,
+		service:         s,
+		dir:             dir,
+		jobs:            make(map[string]*loadJob),
+		queue:           make(chan string, loadQueueSize),
+	}
+}
+
+// Submit stores the upload and queues a job to load it with the given conflict policies.
+// Mileages given as bare numbers in the upload are read in the unit.
+func (l *LoadJobs) Submit(ctx context.Context, upload io.Reader, policies domain.ConflictPolicies, unit domain.Unit) (*domain.LoadJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("generating load job ID: %w", err)
+	}
+
+	path, err := l.store(upload)
+	if err != nil {
+		return nil, fmt.Errorf("storing upload: %w", err)
+	}
+
+	job := &loadJob{
+		job: domain.LoadJob{
+			ID:        id,
+			State:     domain.LoadJobQueued,
+			Policies:  policies,
+			Unit:      unit,
+			CreatedAt: time.Now().UTC(),
+		},
+		path: path,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evict(time.Now().UTC())
+
+	select {
+	case l.queue <- id:
+	default:
+		os.Remove(path)
+		return nil, ErrLoadQueueFull
+	}
+	l.jobs[id] = job
+
+	l.service.Logger.WithContext(ctx).WithField("load_job", id).Info("Queued load job")
+
+	return job.snapshot(), nil
+}
+
+// Get returns the current state of a job.
+func (l *LoadJobs) Get(ctx context.Context, id string) (*domain.LoadJob, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	job, ok := l.jobs[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityLoadJob, Key: id}
+	}
+
+	return job.snapshot(), nil
+}
+
+// Cancel stops a queued or running job, a running job rolls back everything it has loaded.
+// Running jobs stay in the running state until the worker has stopped them.
+func (l *LoadJobs) Cancel(ctx context.Context, id string) (*domain.LoadJob, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	job, ok := l.jobs[id]
+	if !ok {
+		return nil, &domain.NotFoundError{Entity: domain.EntityLoadJob, Key: id}
+	}
+
+	switch job.job.State {
+	case domain.LoadJobQueued:
+		job.finish(domain.LoadJobCancelled)
+	case domain.LoadJobRunning:
+		job.cancelled = true
+		job.cancel()
+	default:
+		return nil, &domain.ConflictError{Entity: domain.EntityLoadJob, Reason: fmt.Sprintf("load job %s has already %s", id, job.job.State)}
+	}
+
+	return job.snapshot(), nil
+}
+
+// Run processes queued jobs until the context is cancelled, jobs still queued then are cancelled.
+func (l *LoadJobs) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			l.cancelQueued()
+			return
+		case id := <-l.queue:
+			l.run(ctx, id)
+		}
+	}
+}
+
+func (l *LoadJobs) run(ctx context.Context, id string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	l.mu.Lock()
+	job, ok := l.jobs[id]
+	if !ok || job.job.State != domain.LoadJobQueued {
+		// Cancelled while it was waiting, and possibly evicted since.
+		l.mu.Unlock()
+		return
+	}
+	startedAt := time.Now().UTC()
+	job.job.State = domain.LoadJobRunning
+	job.job.StartedAt = &startedAt
+	job.cancel = cancel
+	l.mu.Unlock()
+
+	logger := l.service.Logger.WithContext(ctx).WithField("load_job", id)
+	logger.Info("Running load job")
+
+	err := l.load(ctx, job)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case job.cancelled:
+		job.finish(domain.LoadJobCancelled)
+		logger.Info("Cancelled load job")
+	case err != nil:
+		job.job.Errors = append(job.job.Errors, err.Error())
+		var validationErr *LoadValidationError
+		if errors.As(err, &validationErr) {
+			job.job.Issues = validationErr.Issues
+		}
+		job.finish(domain.LoadJobFailed)
+		logger.WithError(err).Error("Failed load job")
+	default:
+		job.finish(domain.LoadJobSucceeded)
+		logger.Info("Finished load job")
+	}
+}
+
+func (l *LoadJobs) load(ctx context.Context, job *loadJob) error {
+	f, err := os.Open(job.path)
+	if err != nil {
+		return fmt.Errorf("opening upload: %w", err)
+	}
+	defer f.Close()
+
+	dec := NewTrackSignalsDecoder(f)
+	dec.Unit = job.job.Unit
+	return l.service.LoadTrackSignals(ctx, dec, job.job.Policies, func(p domain.LoadProgress) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		job.job.Progress = p
+	})
+}
+
+// cancelQueued cancels every job waiting for the worker.
+func (l *LoadJobs) cancelQueued() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for {
+		select {
+		case id := <-l.queue:
+			if job, ok := l.jobs[id]; ok && job.job.State == domain.LoadJobQueued {
+				job.finish(domain.LoadJobCancelled)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// evict drops the finished jobs that are older than the TTL, and then the oldest finished jobs
+// until no more than the maximum are left. The caller must hold the lock.
+func (l *LoadJobs) evict(now time.Time) {
+	var finished []*loadJob
+	for id, job := range l.jobs {
+		switch {
+		case job.job.FinishedAt == nil:
+		case now.Sub(*job.job.FinishedAt) > l.FinishedJobTTL:
+			delete(l.jobs, id)
+		default:
+			finished = append(finished, job)
+		}
+	}
+
+	if len(finished) <= l.MaxFinishedJobs {
+		return
+	}
+	slices.SortFunc(finished, func(a, b *loadJob) int {
+		return a.job.FinishedAt.Compare(*b.job.FinishedAt)
+	})
+	for _, job := range finished[:len(finished)-l.MaxFinishedJobs] {
+		delete(l.jobs, job.job.ID)
+	}
+}
+
+// store copies the upload to a temporary file and returns its path.
+func (l *LoadJobs) store(upload io.Reader) (string, error) {
+	f, err := os.CreateTemp(l.dir, "load-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, upload); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// finish moves the job to its final state and removes its upload, the caller must hold the lock.
+func (j *loadJob) finish(state domain.LoadJobState) {
+	finishedAt := time.Now().UTC()
+	j.job.State = state
+	j.job.FinishedAt = &finishedAt
+	os.Remove(j.path)
+}
+
+// snapshot copies the job so it can be read without the lock, the caller must hold the lock.
+func (j *loadJob) snapshot() *domain.LoadJob {
+	job := j.job
+	job.Errors = append([]string(nil), j.job.Errors...)
+	job.Issues = append([]domain.LoadIssue(nil), j.job.Issues...)
+	return &job
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
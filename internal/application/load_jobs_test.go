@@ -0,0 +1,150 @@
+package application_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestLoadJobs(t *testing.T) {
+	tests := map[string]struct {
+		upload string
+
+		wantState    domain.LoadJobState
+		wantProgress domain.LoadProgress
+		wantErrors   bool
+		wantIssues   []domain.LoadIssue
+	}{
+		"successful load": {
+			upload: `[
+				{"track_id": 1, "source": "A", "target": "B", "signal_ids": [
+					{"signal_id": 1, "signal_name": "SIG1", "elr": "ABC", "mileage": 1.5},
+					{"signal_id": 2, "signal_name": "SIG2", "elr": "ABC", "mileage": 2}
+				]},
+				{"track_id": 2, "source": "B", "target": "C", "signal_ids": []}
+			]`,
+			wantState: domain.LoadJobSucceeded,
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 2},
+				Signals:  domain.WriteCounts{Inserted: 2},
+				Mileages: domain.WriteCounts{Inserted: 2},
+			},
+		},
+		"invalid records": {
+			upload: `[
+				{"track_id": 1, "source": "A", "target": "B", "signal_ids": [
+					{"signal_id": 1, "signal_name": "SIG1", "elr": "ABC", "mileage": NaN}
+				]}
+			]`,
+			wantState: domain.LoadJobFailed,
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Rejected: 1},
+				Signals:  domain.WriteCounts{Rejected: 1},
+				Mileages: domain.WriteCounts{Rejected: 1},
+			},
+			wantErrors: true,
+			wantIssues: []domain.LoadIssue{{Index: 0, TrackID: 1, SignalID: 1, Field: "signal_ids[0].mileage", Reason: "is required"}},
+		},
+		"invalid payload": {
+			upload:     `{"track_id": 1}`,
+			wantState:  domain.LoadJobFailed,
+			wantErrors: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Uploads are kept in the system temp dir as they are by the server.
+			before := uploads(t)
+			jobs := application.NewLoadJobs(newTestService(), os.TempDir())
+			go jobs.Run(ctx)
+
+			job, err := jobs.Submit(ctx, strings.NewReader(test.upload), application.DefaultLoadPolicies, domain.UnitMiles)
+			require.NoError(t, err, "submitting load job")
+			assert.NotEmpty(t, job.ID, "load job ID")
+
+			require.Eventually(t, func() bool {
+				job, err = jobs.Get(ctx, job.ID)
+				require.NoError(t, err, "getting load job")
+				return job.FinishedAt != nil
+			}, 5*time.Second, 10*time.Millisecond, "load job finishing")
+
+			assert.Equal(t, test.wantState, job.State, "load job state")
+			assert.Equal(t, test.wantProgress, job.Progress, "load job progress")
+			assert.Equal(t, test.wantErrors, len(job.Errors) > 0, "load job has errors")
+			assert.Equal(t, test.wantIssues, job.Issues, "load job issues")
+			assert.Equal(t, before, uploads(t), "uploads left after the job finished")
+
+			_, err = jobs.Cancel(ctx, job.ID)
+			require.ErrorIs(t, err, domain.ErrConflict, "cancelling finished load job")
+		})
+	}
+}
+
+func TestLoadJobsCancelQueued(t *testing.T) {
+	ctx := context.Background()
+	// The worker is not running so the job stays queued.
+	before := uploads(t)
+	jobs := application.NewLoadJobs(newTestService(), os.TempDir())
+
+	job, err := jobs.Submit(ctx, strings.NewReader(`[]`), application.DefaultLoadPolicies, domain.UnitMiles)
+	require.NoError(t, err, "submitting load job")
+	assert.Equal(t, domain.LoadJobQueued, job.State, "load job state")
+
+	job, err = jobs.Cancel(ctx, job.ID)
+	require.NoError(t, err, "cancelling load job")
+	assert.Equal(t, domain.LoadJobCancelled, job.State, "load job state")
+	assert.Equal(t, before, uploads(t), "uploads left after the job was cancelled")
+
+	_, err = jobs.Get(ctx, "missing")
+	require.ErrorIs(t, err, domain.ErrNotFound, "getting missing load job")
+}
+
+func TestLoadJobsEviction(t *testing.T) {
+	ctx := context.Background()
+	// The worker is not running, jobs finish by being cancelled.
+	jobs := application.NewLoadJobs(newTestService(), t.TempDir())
+	jobs.MaxFinishedJobs = 1
+
+	finishedJob := func() string {
+		job, err := jobs.Submit(ctx, strings.NewReader(`[]`), application.DefaultLoadPolicies, domain.UnitMiles)
+		require.NoError(t, err, "submitting load job")
+		_, err = jobs.Cancel(ctx, job.ID)
+		require.NoError(t, err, "cancelling load job")
+		return job.ID
+	}
+
+	oldest, kept := finishedJob(), finishedJob()
+	finishedJob()
+
+	_, err := jobs.Get(ctx, oldest)
+	require.ErrorIs(t, err, domain.ErrNotFound, "getting a job past the maximum")
+	_, err = jobs.Get(ctx, kept)
+	require.NoError(t, err, "getting a job within the maximum")
+
+	jobs.FinishedJobTTL = 0
+	finishedJob()
+	_, err = jobs.Get(ctx, kept)
+	require.ErrorIs(t, err, domain.ErrNotFound, "getting a job past the TTL")
+}
+
+// uploads lists the load job uploads in the system temp dir.
+func uploads(t *testing.T) []string {
+	t.Helper()
+
+	names, err := filepath.Glob(filepath.Join(os.TempDir(), "load-*.json"))
+	require.NoError(t, err, "listing uploads")
+	return names
+}
@@ -0,0 +1,262 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// maxLoadIssues bounds the issues collected for a single load.
+const maxLoadIssues = 1000
+
+// LoadValidationError is returned when a load is rejected because of problems with its records.
+type LoadValidationError struct {
+	Issues    []domain.LoadIssue
+	Truncated bool
+}
+
+func (e *LoadValidationError) Error() string {
+	first := e.Issues[0]
+	return fmt.Sprintf("%d problems with records, first at index %d: %s %s", len(e.Issues), first.Index, first.Field, first.Reason)
+}
+
+// add collects the issues, up to maxLoadIssues.
+func (e *LoadValidationError) add(issues ...domain.LoadIssue) {
+	for _, issue := range issues {
+		if len(e.Issues) == maxLoadIssues {
+			e.Truncated = true
+			return
+		}
+		e.Issues = append(e.Issues, issue)
+	}
+}
+
+// ValidateTrackSignals checks every record from the source without writing anything.
+func (s *Service) ValidateTrackSignals(ctx context.Context, source TrackSignalsSource) (*domain.LoadReport, error) {
+	report := &domain.LoadReport{Issues: []domain.LoadIssue{}}
+	validator := s.newLoadValidator()
+
+	elrs, issues, err := sourceELRs(source)
+	if err != nil {
+		report.Issues = append(report.Issues, domain.LoadIssue{Reason: err.Error()})
+		return report, nil
+	}
+	report.Issues = append(report.Issues, issues...)
+	validator.registered(elrs)
+
+	signals, issues, err := sourceSignals(source)
+	if err != nil {
+		report.Issues = append(report.Issues, domain.LoadIssue{Reason: err.Error()})
+		return report, nil
+	}
+	unregistered, err := validator.unregisteredSignals(ctx, signals)
+	if err != nil {
+		return nil, err
+	}
+	report.Signals += len(signals)
+	report.Issues = append(report.Issues, issues...)
+	report.Issues = append(report.Issues, unregistered...)
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ts, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// The rest of the input can't be read once the JSON is malformed.
+			report.Issues = append(report.Issues, domain.LoadIssue{Index: index, Reason: err.Error()})
+			break
+		}
+
+		report.Tracks++
+		report.Signals += len(ts.Signals)
+		issues, err := validator.validate(ctx, index, ts)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if len(report.Issues) == maxLoadIssues {
+				report.Truncated = true
+				break
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+
+	report.Valid = len(report.Issues) == 0
+
+	return report, nil
+}
+
+// sourceSignals returns the signals on no track carried by the source, none when it doesn't carry any,
+// with an issue for each problem with them. The error is only set when they can't be read.
+func sourceSignals(source TrackSignalsSource) ([]domain.Signal, []domain.LoadIssue, error) {
+	signalSource, ok := source.(SignalSource)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	signals, err := signalSource.Signals()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []domain.LoadIssue
+	seen := make(map[int]int, len(signals))
+	for i, signal := range signals {
+		signalIssue := func(field, reason string) {
+			issues = append(issues, domain.LoadIssue{SignalID: signal.ID, Field: fmt.Sprintf("signals[%d].%s", i, field), Reason: reason})
+		}
+
+		switch first, duplicate := seen[signal.ID]; {
+		case idProblem(signal.ID) != "":
+			signalIssue("id", idProblem(signal.ID))
+		case duplicate:
+			signalIssue("id", fmt.Sprintf("duplicates the signal at signals[%d]", first))
+		default:
+			seen[signal.ID] = i
+		}
+
+		// Signals outside the tracks can be stored without an ELR, as they can be created without one.
+		if signal.ELR != "" {
+			if problem := elrProblem(signal.ELR); problem != "" {
+				signalIssue("elr", problem)
+			}
+		}
+		if problem := nameProblem(signal.Name, false); problem != "" {
+			signalIssue("signal_name", problem)
+		}
+	}
+
+	return signals, issues, nil
+}
+
+// loadValidator checks the records of a load one at a time.
+// It keeps nothing from one record to the next apart from whether each ELR is registered, so the registry
+// is only asked once per code and memory use is bounded by the number of ELRs rather than the size of the load.
+// Records that contradict each other are left to the conflict policies when they are written.
+type loadValidator struct {
+	service *Service
+	elrs    map[string]bool
+}
+
+// sourceELRs returns the ELRs carried by the source, none when it doesn't carry any,
+// with an issue for each problem with them. The error is only set when they can't be read.
+func sourceELRs(source TrackSignalsSource) ([]domain.ELR, []domain.LoadIssue, error) {
+	elrSource, ok := source.(ELRSource)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	elrs, err := elrSource.ELRs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []domain.LoadIssue
+	for i, elr := range elrs {
+		var v validation
+		v.elr(elr)
+		for _, field := range v.fields {
+			issues = append(issues, domain.LoadIssue{Field: fmt.Sprintf("elrs[%d].%s", i, field.Field), Reason: field.Reason})
+		}
+	}
+
+	return elrs, issues, nil
+}
+
+func (s *Service) newLoadValidator() *loadValidator {
+	return &loadValidator{
+		service: s,
+		elrs:    make(map[string]bool),
+	}
+}
+
+// unregisteredSignals returns an issue for each signal outside the tracks with an ELR that isn't registered.
+// The error is only set when the ELR registry can't be read.
+func (v *loadValidator) unregisteredSignals(ctx context.Context, signals []domain.Signal) ([]domain.LoadIssue, error) {
+	var issues []domain.LoadIssue
+	for i, signal := range signals {
+		if signal.ELR == "" || elrProblem(signal.ELR) != "" {
+			continue
+		}
+		registered, err := v.service.isRegistered(ctx, v.elrs, signal.ELR)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			issues = append(issues, domain.LoadIssue{SignalID: signal.ID, Field: fmt.Sprintf("signals[%d].elr", i), Reason: "is not registered"})
+		}
+	}
+
+	return issues, nil
+}
+
+// registered treats the ELRs as registered, they are given with the records and registered along with them.
+func (v *loadValidator) registered(elrs []domain.ELR) {
+	for _, elr := range elrs {
+		v.elrs[elr.Code] = true
+	}
+}
+
+// validate returns every issue with the record at index.
+// The error is only set when the ELR registry can't be read.
+func (v *loadValidator) validate(ctx context.Context, index int, ts domain.TrackSignals) ([]domain.LoadIssue, error) {
+	var issues []domain.LoadIssue
+	trackIssue := func(field, reason string) {
+		issues = append(issues, domain.LoadIssue{Index: index, TrackID: ts.ID, Field: field, Reason: reason})
+	}
+
+	check := func(issue func(field, reason string), field, problem string) {
+		if problem != "" {
+			issue(field, problem)
+		}
+	}
+	check(trackIssue, "track_id", idProblem(ts.ID))
+	check(trackIssue, "source", nameProblem(ts.Source, true))
+	check(trackIssue, "target", nameProblem(ts.Target, true))
+	check(trackIssue, "target", locationsProblem(ts.Source, ts.Target))
+
+	onTrack := make(map[int]bool, len(ts.Signals))
+	for i, signal := range ts.Signals {
+		prefix := fmt.Sprintf("signal_ids[%d].", i)
+		signalIssue := func(field, reason string) {
+			issues = append(issues, domain.LoadIssue{Index: index, TrackID: ts.ID, SignalID: signal.ID, Field: prefix + field, Reason: reason})
+		}
+
+		if problem := idProblem(signal.ID); problem != "" {
+			signalIssue("signal_id", problem)
+		} else if onTrack[signal.ID] {
+			signalIssue("signal_id", "appears more than once on the track")
+		}
+		onTrack[signal.ID] = true
+
+		if problem := elrProblem(signal.ELR); problem != "" {
+			signalIssue("elr", problem)
+		} else if registered, err := v.service.isRegistered(ctx, v.elrs, signal.ELR); err != nil {
+			return nil, err
+		} else if !registered {
+			signalIssue("elr", "is not registered")
+		}
+		check(signalIssue, "signal_name", nameProblem(signal.Name, false))
+		var kind validation
+		kind.signalKind("", signal.SignalKind)
+		for _, field := range kind.fields {
+			signalIssue(field.Field, field.Reason)
+		}
+		if signal.Mileage == nil {
+			signalIssue("mileage", "is required")
+		} else {
+			check(signalIssue, "mileage", mileageProblem(*signal.Mileage))
+		}
+	}
+
+	return issues, nil
+}
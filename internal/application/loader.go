@@ -2,51 +2,221 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
-// LoadTrackSignals stores the track signals.
-func (a *Service) LoadTrackSignals(ctx context.Context, trackSignals []domain.TrackSignals) error {
-	logger := a.Logger.WithContext(ctx)
+// DefaultLoadPolicies keep what is already stored for tracks and signals, but fail a load that
+// would move a signal to a different mileage.
+var DefaultLoadPolicies = domain.ConflictPolicies{
+	Tracks:   domain.ConflictSkip,
+	Signals:  domain.ConflictSkip,
+	Mileages: domain.ConflictFail,
+}
+
+// LoadTrackSignals stores the track signals read from the source, one record at a time.
+// The whole load runs in a single transaction, either every track is stored or none are.
+// The policies decide what happens to tracks, signals and mileages that already exist.
+// The optional progress func is called after each track with the running totals.
+func (a *Service) LoadTrackSignals(ctx context.Context, source TrackSignalsSource, policies domain.ConflictPolicies, progress func(domain.LoadProgress)) error {
+	return a.inTransaction(ctx, func(ctx context.Context) error {
+		return a.loadTrackSignals(ctx, source, policies, progress)
+	})
+}
+
+func (a *Service) loadTrackSignals(ctx context.Context, source TrackSignalsSource, policies domain.ConflictPolicies, progress func(domain.LoadProgress)) error {
+	elrs, issues, err := sourceELRs(source)
+	if err != nil {
+		return fmt.Errorf("reading track signals: %w", err)
+	}
+	if len(issues) > 0 {
+		return &LoadValidationError{Issues: issues}
+	}
+	if err := a.registerELRs(ctx, elrs); err != nil {
+		return err
+	}
+
+	validator := a.newLoadValidator()
+	validationErr := &LoadValidationError{}
+
+	var loaded domain.LoadProgress
+	signals, issues, err := sourceSignals(source)
+	if err != nil {
+		return fmt.Errorf("reading track signals: %w", err)
+	}
+	unregistered, err := validator.unregisteredSignals(ctx, signals)
+	if err != nil {
+		return fmt.Errorf("validating signals: %w", err)
+	}
+	issues = append(issues, unregistered...)
+	if len(issues) > 0 {
+		validationErr.add(issues...)
+		loaded.Signals.Rejected += len(signals)
+	} else if err := a.writeSignals(ctx, signals, policies.Signals, &loaded, validationErr); err != nil {
+		return err
+	}
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ts, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading track signals: %w", err)
+		}
 
-	for _, ts := range trackSignals {
-		err := a.TrackStore.CreateTrack(ctx, &domain.Track{
-			ID:     ts.ID,
-			Source: ts.Source,
-			Target: ts.Target,
-		})
+		// A rejected record fails the whole load, the rest of the file is still loaded so that
+		// every problem and the full counts are reported at once before it is rolled back.
+		issues, err := validator.validate(ctx, index, ts)
 		if err != nil {
-			logger.WithError(err).Error("Failed to store track while loading track signals")
-			return fmt.Errorf("creating track: %w", err)
+			return fmt.Errorf("validating track %d at index %d: %w", ts.ID, index, err)
 		}
+		if len(issues) > 0 {
+			validationErr.add(issues...)
+			loaded.Tracks.Rejected++
+			loaded.Signals.Rejected += len(ts.Signals)
+			loaded.Mileages.Rejected += len(ts.Signals)
+		} else {
+			err := a.writeTrackSignals(ctx, ts, policies, &loaded)
 
-		for _, signal := range ts.Signals {
-			// TODO: this needs to be removed when DB migration issues are fixed.
-			if signal.ELR == "" {
-				signal.ELR = "NULL"
-			}
-			err := a.SignalStore.CreateSignal(ctx, &domain.Signal{
-				ID:   signal.ID,
-				Name: signal.Name,
-				ELR:  signal.ELR,
-			})
-			if err != nil {
-				logger.WithError(err).Error("Failed to store signal while loading track signals")
-				return fmt.Errorf("creating signal: %w", err)
+			var rejected *rejectedError
+			switch {
+			case errors.As(err, &rejected):
+				validationErr.add(domain.LoadIssue{
+					Index:    index,
+					TrackID:  ts.ID,
+					SignalID: rejected.signalID,
+					Field:    rejected.field,
+					Reason:   rejected.Error(),
+				})
+			case err != nil:
+				return fmt.Errorf("storing track %d at index %d: %w", ts.ID, index, err)
 			}
+		}
 
-			err = a.MileageStore.AddMileage(ctx, &domain.Mileage{
-				SignalID: signal.ID,
-				TrackID:  ts.ID,
-				Mileage:  signal.Mileage,
-			})
-			if err != nil {
-				logger.WithError(err).Error("Failed to store signal mileage while loading track signals")
-				return fmt.Errorf("creating mileage: %w", err)
-			}
+		if progress != nil {
+			progress(loaded)
+		}
+	}
+
+	if len(validationErr.Issues) > 0 {
+		return validationErr
+	}
+
+	return nil
+}
+
+// writeSignals writes the signals that aren't on any track, counting the outcome of every write in counts.
+// Signals the policy rejects are added to validationErr.
+func (a *Service) writeSignals(ctx context.Context, signals []domain.Signal, policy domain.ConflictPolicy, counts *domain.LoadProgress, validationErr *LoadValidationError) error {
+	for i, signal := range signals {
+		outcome, err := a.signals(ctx).CreateSignal(ctx, &signal, policy)
+		if errors.Is(err, domain.ErrConflict) {
+			counts.Signals.Rejected++
+			validationErr.add(domain.LoadIssue{SignalID: signal.ID, Field: fmt.Sprintf("signals[%d].id", i), Reason: err.Error()})
+			continue
+		}
+		if err != nil {
+			a.Logger.WithContext(ctx).WithError(err).Error("Failed to store signal")
+			return fmt.Errorf("storing signal %d at signals[%d]: %w", signal.ID, i, err)
 		}
+		counts.Signals.Add(outcome)
 	}
+
+	return nil
+}
+
+// registerELRs registers the ELRs given with the records, keeping any that are already registered.
+func (a *Service) registerELRs(ctx context.Context, elrs []domain.ELR) error {
+	for _, elr := range elrs {
+		if _, err := a.elrs(ctx).CreateELR(ctx, &elr, domain.ConflictSkip); err != nil {
+			a.Logger.WithContext(ctx).WithError(err).Error("Failed to register ELR")
+			return fmt.Errorf("registering ELR %s: %w", elr.Code, err)
+		}
+	}
+
+	return nil
+}
+
+// rejectedError is returned by writeTrackSignals when the store rejects the record because an
+// existing entity conflicts with it and the policy for it is to fail.
+type rejectedError struct {
+	signalID int
+	field    string
+	err      error
+}
+
+func (e *rejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rejectedError) Unwrap() error {
+	return e.err
+}
+
+// writeTrackSignals writes a single track with its signals and their mileages,
+// counting the outcome of every write in counts.
+func (a *Service) writeTrackSignals(ctx context.Context, ts domain.TrackSignals, policies domain.ConflictPolicies, counts *domain.LoadProgress) error {
+	logger := a.Logger.WithContext(ctx)
+
+	outcome, err := a.tracks(ctx).CreateTrack(ctx, &domain.Track{
+		ID:     ts.ID,
+		Source: ts.Source,
+		Target: ts.Target,
+	}, policies.Tracks)
+	if errors.Is(err, domain.ErrConflict) {
+		counts.Tracks.Rejected++
+		return &rejectedError{field: "track_id", err: err}
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to store track")
+		return fmt.Errorf("creating track: %w", err)
+	}
+	counts.Tracks.Add(outcome)
+
+	for i, signal := range ts.Signals {
+		if signal.Mileage == nil {
+			return fmt.Errorf("signal %d has no mileage", signal.ID)
+		}
+
+		outcome, err := a.signals(ctx).CreateSignal(ctx, &domain.Signal{
+			ID:         signal.ID,
+			Name:       signal.Name,
+			ELR:        signal.ELR,
+			SignalKind: signal.SignalKind,
+		}, policies.Signals)
+		if errors.Is(err, domain.ErrConflict) {
+			counts.Signals.Rejected++
+			return &rejectedError{signalID: signal.ID, field: fmt.Sprintf("signal_ids[%d].signal_id", i), err: err}
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to store signal")
+			return fmt.Errorf("creating signal %d: %w", signal.ID, err)
+		}
+		counts.Signals.Add(outcome)
+
+		outcome, err = a.mileages(ctx).AddMileage(ctx, &domain.Mileage{
+			SignalID: signal.ID,
+			TrackID:  ts.ID,
+			Mileage:  *signal.Mileage,
+		}, policies.Mileages)
+		if errors.Is(err, domain.ErrConflict) {
+			counts.Mileages.Rejected++
+			return &rejectedError{signalID: signal.ID, field: fmt.Sprintf("signal_ids[%d].mileage", i), err: err}
+		}
+		if err != nil {
+			logger.WithError(err).Error("Failed to store signal mileage")
+			return fmt.Errorf("creating mileage for signal %d: %w", signal.ID, err)
+		}
+		counts.Mileages.Add(outcome)
+	}
+
 	return nil
 }
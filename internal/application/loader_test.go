@@ -0,0 +1,337 @@
+package application_test
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/adapters/memory"
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// newTestService returns a service backed by an empty in-memory store with the test ELRs registered.
+func newTestService() *application.Service {
+	s := newEmptyService()
+	for _, code := range []string{"ABC", "XYZ", "ECM1"} {
+		if _, err := s.ELRStore.CreateELR(context.Background(), &domain.ELR{Code: code}, domain.ConflictFail); err != nil {
+			panic(err)
+		}
+	}
+
+	return s
+}
+
+// newEmptyService returns a service backed by an empty in-memory store, without any ELRs registered.
+func newEmptyService() *application.Service {
+	repo := memory.NewRepository()
+	return &application.Service{
+		Logger:       logrus.New(),
+		SignalStore:  repo,
+		TrackStore:   repo,
+		MileageStore: repo,
+		ELRStore:     repo,
+		RouteStore:   repo,
+		PointStore:   repo,
+		Transactor:   repo,
+	}
+}
+
+func TestLoadTrackSignals(t *testing.T) {
+	tests := map[string]struct {
+		existing *domain.TrackSignals
+		input    domain.TrackSignalSlice
+		policies domain.ConflictPolicies
+
+		wantTrackIDs []int
+		wantProgress domain.LoadProgress
+		wantIssues   []domain.LoadIssue
+	}{
+		"loads every track": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)}}},
+			},
+			policies:     application.DefaultLoadPolicies,
+			wantTrackIDs: []int{1, 2},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 2},
+				Signals:  domain.WriteCounts{Inserted: 1, Skipped: 1},
+				Mileages: domain.WriteCounts{Inserted: 2},
+			},
+		},
+		"invalid records reject the whole load": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B"},
+				{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{{ID: 2, Name: "SIG2"}}},
+			},
+			policies:     application.DefaultLoadPolicies,
+			wantTrackIDs: []int{},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 1, Rejected: 2},
+				Signals:  domain.WriteCounts{Inserted: 1, Rejected: 1},
+				Mileages: domain.WriteCounts{Inserted: 1, Rejected: 1},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 1, TrackID: 2, Field: "target", Reason: "is required"},
+				{Index: 2, TrackID: 3, SignalID: 2, Field: "signal_ids[0].elr", Reason: "is required"},
+				{Index: 2, TrackID: 3, SignalID: 2, Field: "signal_ids[0].mileage", Reason: "is required"},
+			},
+		},
+		"conflict part way through rolls back the whole load": {
+			existing: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(5)},
+			}},
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)}}},
+			},
+			policies:     application.DefaultLoadPolicies,
+			wantTrackIDs: []int{2},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 1, Skipped: 1},
+				Signals:  domain.WriteCounts{Skipped: 2},
+				Mileages: domain.WriteCounts{Inserted: 1, Rejected: 1},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 1, TrackID: 2, SignalID: 1, Field: "signal_ids[0].mileage", Reason: "signal 1 already has a different mileage on track 2"},
+			},
+		},
+		"unregistered elr part way through rejects the whole load": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 2, Name: "SIG2", ELR: "ZZZ", Mileage: miles(2)}}},
+				{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: miles(3)}}},
+			},
+			policies:     application.DefaultLoadPolicies,
+			wantTrackIDs: []int{},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 2, Rejected: 1},
+				Signals:  domain.WriteCounts{Inserted: 2, Rejected: 1},
+				Mileages: domain.WriteCounts{Inserted: 2, Rejected: 1},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 1, TrackID: 2, SignalID: 2, Field: "signal_ids[0].elr", Reason: "is not registered"},
+			},
+		},
+		"records that contradict each other are rejected by a fail policy": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 1, Name: "RENAMED", ELR: "ABC", Mileage: miles(2)}}},
+			},
+			policies:     failOnConflict,
+			wantTrackIDs: []int{},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Inserted: 2},
+				Signals:  domain.WriteCounts{Inserted: 1, Rejected: 1},
+				Mileages: domain.WriteCounts{Inserted: 1},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 1, TrackID: 2, SignalID: 1, Field: "signal_ids[0].signal_id", Reason: "signal 1 already exists with a different name, ELR or kind"},
+			},
+		},
+		"upsert overwrites what is stored": {
+			existing: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "OLD", ELR: "ABC", Mileage: miles(5)},
+			}},
+			input: domain.TrackSignalSlice{
+				{ID: 2, Source: "B", Target: "D", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)}}},
+			},
+			policies: domain.ConflictPolicies{
+				Tracks:   domain.ConflictUpsert,
+				Signals:  domain.ConflictUpsert,
+				Mileages: domain.ConflictUpsert,
+			},
+			wantTrackIDs: []int{2},
+			wantProgress: domain.LoadProgress{
+				Tracks:   domain.WriteCounts{Updated: 1},
+				Signals:  domain.WriteCounts{Updated: 1},
+				Mileages: domain.WriteCounts{Updated: 1},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			if test.existing != nil {
+				_, err := s.CreateTrackSignals(ctx, test.existing, domain.ConflictPolicies{})
+				require.NoError(t, err, "creating existing track")
+			}
+
+			var progress domain.LoadProgress
+			err := s.LoadTrackSignals(ctx, application.SliceSource(test.input), test.policies, func(p domain.LoadProgress) {
+				progress = p
+			})
+			if test.wantIssues != nil {
+				var validationErr *application.LoadValidationError
+				require.ErrorAs(t, err, &validationErr, "load validation error")
+				assert.Equal(t, test.wantIssues, validationErr.Issues, "load issues")
+			} else {
+				require.NoError(t, err, "loading track signals")
+			}
+			assert.Equal(t, test.wantProgress, progress, "load progress")
+
+			tracks, _, err := s.ListTracks(ctx, 100, 0)
+			require.NoError(t, err, "listing tracks")
+			trackIDs := []int{}
+			for _, track := range tracks {
+				trackIDs = append(trackIDs, track.ID)
+			}
+			assert.Equal(t, test.wantTrackIDs, trackIDs, "stored tracks")
+		})
+	}
+}
+
+func TestValidateTrackSignals(t *testing.T) {
+	long := strings.Repeat("S", 256)
+
+	tests := map[string]struct {
+		input domain.TrackSignalSlice
+
+		wantIssues []domain.LoadIssue
+	}{
+		"valid file": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)}}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)}}},
+			},
+			wantIssues: []domain.LoadIssue{},
+		},
+		"track problems": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "A"},
+				{Source: "A", Target: "B"},
+				{ID: 1, Source: "A", Target: "B"},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 0, TrackID: 1, Field: "target", Reason: "must differ from source"},
+				{Index: 1, Field: "track_id", Reason: "is required"},
+			},
+		},
+		"signal problems": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABCDE", Mileage: miles(1)},
+					{ID: 2, Name: long, ELR: "ABC"},
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+				}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "RENAMED", ELR: "ABCDE", Mileage: miles(1)},
+					{ID: 3, Name: "SIG3", ELR: "ZZZ", Mileage: miles(3)},
+				}},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 0, TrackID: 1, SignalID: 1, Field: "signal_ids[0].elr", Reason: "is longer than 4 characters"},
+				{Index: 0, TrackID: 1, SignalID: 2, Field: "signal_ids[1].signal_name", Reason: "is longer than 255 characters"},
+				{Index: 0, TrackID: 1, SignalID: 2, Field: "signal_ids[1].mileage", Reason: "is required"},
+				{Index: 0, TrackID: 1, SignalID: 2, Field: "signal_ids[2].signal_id", Reason: "appears more than once on the track"},
+				{Index: 1, TrackID: 2, SignalID: 1, Field: "signal_ids[0].elr", Reason: "is longer than 4 characters"},
+				{Index: 1, TrackID: 2, SignalID: 3, Field: "signal_ids[1].elr", Reason: "is not registered"},
+			},
+		},
+		"signal kind problems": {
+			input: domain.TrackSignalSlice{
+				{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+					{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1), SignalKind: domain.SignalKind{Type: "semaphore"}},
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2), SignalKind: domain.SignalKind{Type: domain.SignalMain, Aspects: 4}},
+				}},
+				{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+					{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2), SignalKind: domain.SignalKind{Type: domain.SignalMain, Aspects: 3}},
+				}},
+			},
+			wantIssues: []domain.LoadIssue{
+				{Index: 0, TrackID: 1, SignalID: 1, Field: "signal_ids[0].type", Reason: "must be main, distant, shunt, banner_repeater or stop_board"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newTestService()
+
+			report, err := s.ValidateTrackSignals(context.Background(), application.SliceSource(test.input))
+			require.NoError(t, err, "validating track signals")
+			assert.Equal(t, test.wantIssues, report.Issues, "load issues")
+			assert.Equal(t, len(test.wantIssues) == 0, report.Valid, "load is valid")
+			assert.Equal(t, len(test.input), report.Tracks, "tracks checked")
+
+			tracks, _, err := s.ListTracks(context.Background(), 100, 0)
+			require.NoError(t, err, "listing tracks")
+			assert.Empty(t, tracks, "dry run stores nothing")
+		})
+	}
+}
+
+func TestValidateTrackSignalsOutsideTracks(t *testing.T) {
+	input := `{"signals": [
+		{"signal_name": "SIG0"},
+		{"id": 5, "signal_name": "SIG5", "elr": "ABCDE"},
+		{"id": 5, "signal_name": "SIG5"},
+		{"id": 6, "signal_name": "SIG6", "elr": "ZZZ"}
+	], "tracks": []}`
+
+	report, err := newTestService().ValidateTrackSignals(context.Background(), application.NewTrackSignalsDecoder(strings.NewReader(input)))
+	require.NoError(t, err, "validating track signals")
+	assert.Equal(t, []domain.LoadIssue{
+		{Field: "signals[0].id", Reason: "is required"},
+		{SignalID: 5, Field: "signals[1].elr", Reason: "is longer than 4 characters"},
+		{SignalID: 5, Field: "signals[2].id", Reason: "duplicates the signal at signals[1]"},
+		{SignalID: 6, Field: "signals[3].elr", Reason: "is not registered"},
+	}, report.Issues, "load issues")
+	assert.Equal(t, 4, report.Signals, "signals checked")
+}
+
+// syntheticSource generates its records on demand, so nothing but the validator holds on to them.
+type syntheticSource struct {
+	next, count int
+	// at is called before the record with its index is returned.
+	at map[int]func()
+}
+
+func (s *syntheticSource) Next() (domain.TrackSignals, error) {
+	if s.next == s.count {
+		return domain.TrackSignals{}, io.EOF
+	}
+	if fn := s.at[s.next]; fn != nil {
+		fn()
+	}
+	s.next++
+
+	return domain.TrackSignals{ID: s.next, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+		{ID: s.next, Name: strings.Repeat("S", 32), ELR: "ABC", Mileage: miles(1)},
+	}}, nil
+}
+
+func TestValidateTrackSignalsHoldsNothingPerRecord(t *testing.T) {
+	const records = 200_000
+
+	// The heap is measured part way through and at the last record, while the validator is still in use.
+	var before, after runtime.MemStats
+	source := &syntheticSource{count: records, at: map[int]func(){
+		1000: func() {
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+		},
+		records - 1: func() {
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+		},
+	}}
+
+	report, err := newTestService().ValidateTrackSignals(context.Background(), source)
+	require.NoError(t, err, "validating track signals")
+
+	assert.True(t, report.Valid, "load is valid")
+	assert.Equal(t, records, report.Tracks, "tracks checked")
+	// Remembering every track and signal costs tens of megabytes for this many records.
+	assert.Less(t, int64(after.HeapAlloc)-int64(before.HeapAlloc), int64(1<<20), "heap growth while validating")
+}
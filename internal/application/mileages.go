@@ -0,0 +1,48 @@
+package application
+
+import (
+	"context"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func (s *Service) GetMileage(ctx context.Context, signalID, trackID int) (*domain.Mileage, error) {
+	return s.mileages(ctx).GetMileage(ctx, signalID, trackID)
+}
+
+// SetMileage places the signal on the track at the mileage, moving it if it is already on the track.
+// Missing IDs in the body are taken from the path, different ones are a validation error.
+func (s *Service) SetMileage(ctx context.Context, signalID, trackID int, mileage *domain.Mileage) (domain.WriteOutcome, error) {
+	var v validation
+	matchPathKey(&v, "signal_id", signalID, &mileage.SignalID)
+	matchPathKey(&v, "track_id", trackID, &mileage.TrackID)
+	v.mileage(*mileage)
+	if err := v.err(); err != nil {
+		return "", err
+	}
+
+	return s.mileages(ctx).AddMileage(ctx, mileage, domain.ConflictUpsert)
+}
+
+// DeleteMileage removes the signal from the track, returning domain.ErrNotFound if it isn't on the track.
+func (s *Service) DeleteMileage(ctx context.Context, signalID, trackID int) error {
+	return s.mileages(ctx).DeleteMileage(ctx, signalID, trackID)
+}
+
+func (s *Service) ListTrackMileages(ctx context.Context, trackID, limit, page int) ([]domain.Mileage, int, error) {
+	mileages, count, err := s.mileages(ctx).ListTrackMileages(ctx, trackID, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return mileages, nextPage(limit, page, count), nil
+}
+
+func (s *Service) ListSignalMileages(ctx context.Context, signalID, limit, page int) ([]domain.Mileage, int, error) {
+	mileages, count, err := s.mileages(ctx).ListSignalMileages(ctx, signalID, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return mileages, nextPage(limit, page, count), nil
+}
@@ -0,0 +1,418 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// Limits on a movement simulation, so that a single request can't run for long.
+const (
+	defaultTick     = 1.0
+	maxTick         = 60.0
+	defaultDuration = 3600.0
+	maxDuration     = 86400.0
+	maxTicks        = 100000
+	maxTrains       = 50
+)
+
+const (
+	yardsPerMetre       = 1 / 0.9144
+	yardsPerSecondInMPH = 1760.0 / 3600
+)
+
+// SimulateMovements runs the trains along the shortest paths by length between their locations and returns the timeline.
+//
+// Time advances in ticks. Each tick every train that has departed accelerates towards its maximum speed,
+// braking to stop at the next red signal on its path or at its destination. A train occupies every block
+// section it overlaps and leaves the network when it arrives. After the trains have moved the aspects are
+// sequenced along the path of each train as SimulateAspects does, where signals seen in the same direction
+// along a track by more than one train show the most restrictive of their aspects. Only main signals stop trains.
+func (s *Service) SimulateMovements(ctx context.Context, plan domain.MovementPlan) (*domain.MovementLog, error) {
+	if plan.Tick == 0 {
+		plan.Tick = defaultTick
+	}
+	if plan.Duration == 0 {
+		plan.Duration = defaultDuration
+	}
+
+	var v validation
+	v.movementPlan(plan)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	network, err := s.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*trainRun, 0, len(plan.Trains))
+	for i, train := range plan.Trains {
+		path, err := network.ShortestPath(train.From, train.To, WeightLength)
+		if err != nil {
+			return nil, fmt.Errorf("train %s: %w", train.ID, err)
+		}
+
+		run, problem := newTrainRun(train, path)
+		v.check(fmt.Sprintf("trains[%d]", i), problem)
+		runs = append(runs, run)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	return simulateMovements(plan, runs), nil
+}
+
+// movementPlan checks the settings of a movement simulation and the trains in it.
+func (v *validation) movementPlan(plan domain.MovementPlan) {
+	if plan.Tick < 0 || plan.Tick > maxTick {
+		v.check("tick", fmt.Sprintf("must be between 0 and %g seconds", maxTick))
+	}
+	switch {
+	case plan.Duration < 0 || plan.Duration > maxDuration:
+		v.check("duration", fmt.Sprintf("must be between 0 and %g seconds", maxDuration))
+	case plan.Tick > 0 && plan.Duration/plan.Tick > maxTicks:
+		v.check("duration", fmt.Sprintf("must be at most %d ticks", maxTicks))
+	}
+
+	switch {
+	case len(plan.Trains) == 0:
+		v.check("trains", "is required")
+	case len(plan.Trains) > maxTrains:
+		v.check("trains", fmt.Sprintf("must be at most %d trains", maxTrains))
+	}
+
+	ids := make(map[string]bool, len(plan.Trains))
+	for i, train := range plan.Trains {
+		prefix := fmt.Sprintf("trains[%d].", i)
+		v.check(prefix+"id", nameProblem(train.ID, true))
+		if train.ID != "" && ids[train.ID] {
+			v.check(prefix+"id", "appears more than once")
+		}
+		ids[train.ID] = true
+
+		v.check(prefix+"from", nameProblem(train.From, true))
+		v.check(prefix+"to", nameProblem(train.To, true))
+		if train.From != "" && train.From == train.To {
+			v.check(prefix+"to", "must not be the same as from")
+		}
+
+		v.check(prefix+"length", positiveProblem(float64(train.Length)))
+		v.check(prefix+"max_speed", positiveProblem(train.MaxSpeed))
+		v.check(prefix+"acceleration", positiveProblem(train.Acceleration))
+		v.check(prefix+"braking", positiveProblem(train.Braking))
+		if train.Depart < 0 {
+			v.check(prefix+"depart", "must not be negative")
+		}
+	}
+}
+
+func positiveProblem(value float64) string {
+	if value <= 0 {
+		return "must be positive"
+	}
+	return ""
+}
+
+// lineSignal is a signal on the path of a train, at a position in yards from the first signal on the path.
+type lineSignal struct {
+	domain.TrackSignal
+	trackID   int
+	direction domain.Direction
+	position  float64
+}
+
+// section is the stretch of line between two signals, whichever way it is travelled.
+type section struct {
+	from, to int
+}
+
+func sectionBetween(a, b lineSignal) section {
+	return section{from: min(a.ID, b.ID), to: max(a.ID, b.ID)}
+}
+
+// trainRun is a train moving along its path, distances are in yards and times in seconds.
+type trainRun struct {
+	train                  domain.Train
+	line                   []lineSignal
+	signals                []domain.TrackSignal
+	aspects                []domain.Aspect
+	maxSpeed, acceleration float64
+	braking, length        float64
+
+	state     domain.TrainState
+	departed  bool
+	position  float64
+	speed     float64
+	stoppedAt int
+}
+
+// newTrainRun lays out the signals on the path of the train, it returns a problem when they can't be placed.
+// A signal at the junction of two tracks is on both of them, it is only placed once.
+func newTrainRun(train domain.Train, path *domain.Path) (*trainRun, string) {
+	reversed := make(map[int]bool, len(path.Tracks))
+	for _, track := range path.Tracks {
+		reversed[track.ID] = track.Reversed
+	}
+
+	var line []lineSignal
+	for _, signal := range path.Signals {
+		placed := lineSignal{TrackSignal: signal.TrackSignal, trackID: signal.TrackID, direction: domain.DirectionDown}
+		if reversed[signal.TrackID] {
+			placed.direction = domain.DirectionUp
+		}
+
+		if len(line) > 0 {
+			last := line[len(line)-1]
+			if last.ID == signal.ID {
+				continue
+			}
+
+			distance := signalDistance(last.TrackSignal, signal.TrackSignal)
+			if distance == nil {
+				return nil, fmt.Sprintf("the path passes signals %d and %d on different ELRs, the distance between them is unknown", last.ID, signal.ID)
+			}
+			placed.position = last.position + float64(*distance)
+		}
+		line = append(line, placed)
+	}
+	if len(line) < 2 {
+		return nil, fmt.Sprintf("the path from %s to %s passes fewer than two signals", train.From, train.To)
+	}
+
+	run := &trainRun{
+		train:        train,
+		line:         line,
+		signals:      make([]domain.TrackSignal, len(line)),
+		aspects:      make([]domain.Aspect, len(line)),
+		maxSpeed:     train.MaxSpeed * yardsPerSecondInMPH,
+		acceleration: train.Acceleration * yardsPerMetre,
+		braking:      train.Braking * yardsPerMetre,
+		length:       float64(train.Length),
+		state:        domain.TrainWaiting,
+		stoppedAt:    -1,
+	}
+	for i, signal := range line {
+		run.signals[i] = signal.TrackSignal
+	}
+
+	return run, ""
+}
+
+// simulateMovements runs the trains tick by tick until they have all arrived or the time is up.
+func simulateMovements(plan domain.MovementPlan, runs []*trainRun) *domain.MovementLog {
+	log := &domain.MovementLog{Trains: make([]domain.TrainProgress, 0, len(runs)), Events: []domain.TrainEvent{}}
+
+	aspects := sequenceAspects(runs)
+	ticks := int(math.Ceil(plan.Duration / plan.Tick))
+	for tick := 1; tick <= ticks && !allArrived(runs); tick++ {
+		start := float64(tick-1) * plan.Tick
+		log.Duration = float64(tick) * plan.Tick
+
+		for _, run := range runs {
+			if run.state != domain.TrainArrived && start >= run.train.Depart {
+				log.Events = append(log.Events, run.move(start, plan.Tick)...)
+			}
+		}
+
+		next := sequenceAspects(runs)
+		log.Events = append(log.Events, aspectChanges(log.Duration, aspects, next)...)
+		aspects = next
+	}
+
+	// Trains moving in the same tick are interleaved by the time of each event.
+	slices.SortStableFunc(log.Events, func(a, b domain.TrainEvent) int {
+		return cmp.Compare(a.Time, b.Time)
+	})
+
+	for _, run := range runs {
+		log.Trains = append(log.Trains, domain.TrainProgress{
+			ID:        run.train.ID,
+			State:     run.state,
+			Travelled: domain.Distance(math.Round(run.position)),
+			Speed:     mph(run.speed),
+		})
+	}
+
+	return log
+}
+
+// move advances the train by one tick from start, returning what happened to it.
+func (r *trainRun) move(start, tick float64) []domain.TrainEvent {
+	// The train has to stop at the next red signal ahead of it, or at the end of its path.
+	stop, red := len(r.line)-1, false
+	for i, signal := range r.line {
+		if signal.position >= r.position && r.aspects[i] == domain.AspectRed {
+			stop, red = i, true
+			break
+		}
+	}
+
+	toGo := r.line[stop].position - r.position
+	r.speed = max(0, min(r.speed+r.acceleration*tick, r.maxSpeed, math.Sqrt(2*r.braking*toGo)))
+	travel := min(r.speed*tick, toGo)
+	from := r.position
+	r.position += travel
+
+	// at is the time the train reached a position during the tick.
+	at := func(position float64) float64 {
+		if r.speed == 0 {
+			return round(start)
+		}
+		return round(start + (position-from)/r.speed)
+	}
+
+	var events []domain.TrainEvent
+	if !r.departed && travel > 0 {
+		r.departed = true
+		events = append(events, r.event(start, domain.EventDeparted, r.line[0]))
+	}
+	for i, signal := range r.line {
+		if signal.position >= from && signal.position < r.position {
+			passed := r.event(at(signal.position), domain.EventSignalPassed, signal)
+			passed.Aspect = r.aspects[i]
+			speed := mph(r.speed)
+			passed.Speed = &speed
+			events = append(events, passed)
+		}
+	}
+
+	switch {
+	case travel < toGo:
+		r.state, r.stoppedAt = domain.TrainRunning, -1
+	case red:
+		r.speed, r.state = 0, domain.TrainStopped
+		if r.stoppedAt != stop {
+			r.stoppedAt = stop
+			stopped := r.event(at(r.position), domain.EventStoppedAtRed, r.line[stop])
+			stopped.Aspect = domain.AspectRed
+			events = append(events, stopped)
+		}
+	default:
+		r.speed, r.state = 0, domain.TrainArrived
+		events = append(events, r.event(at(r.position), domain.EventArrived, r.line[stop]))
+	}
+
+	return events
+}
+
+func (r *trainRun) event(time float64, eventType domain.TrainEventType, signal lineSignal) domain.TrainEvent {
+	return domain.TrainEvent{
+		Time:      time,
+		Type:      eventType,
+		Train:     r.train.ID,
+		TrackID:   signal.trackID,
+		SignalID:  signal.ID,
+		Direction: signal.direction,
+	}
+}
+
+// occupies adds the sections the train overlaps to occupied, trains that have arrived have left the network.
+func (r *trainRun) occupies(occupied map[section]bool) {
+	if r.state == domain.TrainArrived {
+		return
+	}
+
+	rear := r.position - r.length
+	for i := 1; i < len(r.line); i++ {
+		if r.line[i-1].position < r.position && r.line[i].position > rear {
+			occupied[sectionBetween(r.line[i-1], r.line[i])] = true
+		}
+	}
+}
+
+// aspectKey is a signal as seen by trains travelling in the direction along the track.
+type aspectKey struct {
+	trackID   int
+	signalID  int
+	direction domain.Direction
+}
+
+// sequenceAspects sequences the aspects along the path of every train for the sections the trains occupy.
+func sequenceAspects(runs []*trainRun) map[aspectKey]domain.Aspect {
+	occupied := make(map[section]bool)
+	for _, run := range runs {
+		run.occupies(occupied)
+	}
+
+	aspects := make(map[aspectKey]domain.Aspect)
+	for _, run := range runs {
+		entries := make(map[int]bool)
+		for i := 1; i < len(run.line); i++ {
+			if occupied[sectionBetween(run.line[i-1], run.line[i])] {
+				entries[run.line[i-1].ID] = true
+			}
+		}
+
+		for i, signal := range simulateAspects(run.signals, entries) {
+			run.aspects[i] = signal.Aspect
+			if signal.Aspect == "" {
+				continue
+			}
+
+			key := aspectKey{trackID: run.line[i].trackID, signalID: signal.ID, direction: run.line[i].direction}
+			if current, ok := aspects[key]; !ok || moreRestrictive(signal.Aspect, current) {
+				aspects[key] = signal.Aspect
+			}
+		}
+	}
+
+	return aspects
+}
+
+// aspectChanges lists the signals showing a different aspect after the tick, ordered by track and signal.
+func aspectChanges(time float64, before, after map[aspectKey]domain.Aspect) []domain.TrainEvent {
+	var events []domain.TrainEvent
+	for key, aspect := range after {
+		if before[key] == aspect {
+			continue
+		}
+		events = append(events, domain.TrainEvent{
+			Time:           round(time),
+			Type:           domain.EventAspectChanged,
+			TrackID:        key.trackID,
+			SignalID:       key.signalID,
+			Direction:      key.direction,
+			Aspect:         aspect,
+			PreviousAspect: before[key],
+		})
+	}
+
+	slices.SortFunc(events, func(a, b domain.TrainEvent) int {
+		return cmp.Or(cmp.Compare(a.TrackID, b.TrackID), cmp.Compare(a.SignalID, b.SignalID), cmp.Compare(a.Direction, b.Direction))
+	})
+	return events
+}
+
+// aspectRestriction is the aspects from the one that tells a driver most to slow down to the least.
+var aspectRestriction = []domain.Aspect{domain.AspectRed, domain.AspectYellow, domain.AspectDoubleYellow, domain.AspectGreen}
+
+// moreRestrictive reports whether aspect a tells a driver more to slow down than b.
+func moreRestrictive(a, b domain.Aspect) bool {
+	return slices.Index(aspectRestriction, a) < slices.Index(aspectRestriction, b)
+}
+
+func allArrived(runs []*trainRun) bool {
+	for _, run := range runs {
+		if run.state != domain.TrainArrived {
+			return false
+		}
+	}
+	return true
+}
+
+// mph converts a speed in yards per second to miles per hour, to one decimal place.
+func mph(yardsPerSecond float64) float64 {
+	return math.Round(yardsPerSecond/yardsPerSecondInMPH*10) / 10
+}
+
+// round rounds a time to the millisecond.
+func round(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
@@ -0,0 +1,121 @@
+package application_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestSimulateMovements(t *testing.T) {
+	main4 := domain.SignalKind{Type: domain.SignalMain, Aspects: 4, Automatic: true}
+	network := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 1, ELR: "ABC", Mileage: miles(0), SignalKind: main4},
+			{ID: 2, ELR: "ABC", Mileage: miles(1), SignalKind: main4},
+			{ID: 3, ELR: "ABC", Mileage: miles(2), SignalKind: main4},
+		}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 3, ELR: "ABC", Mileage: miles(2), SignalKind: main4},
+			{ID: 4, ELR: "ABC", Mileage: miles(3), SignalKind: main4},
+		}},
+		{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{
+			{ID: 5, ELR: "XYZ", Mileage: miles(10), SignalKind: main4},
+		}},
+		// A direct line from A to C that is fewer tracks but further than the way through B.
+		{ID: 4, Source: "A", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 6, ELR: "ABC", Mileage: miles(0), SignalKind: main4},
+			{ID: 7, ELR: "ABC", Mileage: miles(10), SignalKind: main4},
+		}},
+	}
+	train := func(id string, depart float64) domain.Train {
+		return domain.Train{ID: id, From: "A", To: "C", Length: 220, MaxSpeed: 100, Acceleration: 0.5, Braking: 0.7, Depart: depart}
+	}
+
+	tests := map[string]struct {
+		plan domain.MovementPlan
+
+		wantStates []domain.TrainState
+		// wantEvents are the train events of each train in order, without their times.
+		wantEvents map[string][]string
+		wantErr    error
+	}{
+		"single train": {
+			plan:       domain.MovementPlan{Trains: []domain.Train{train("1A01", 0)}},
+			wantStates: []domain.TrainState{domain.TrainArrived},
+			wantEvents: map[string][]string{
+				"1A01": {"departed 1", "signal_passed 1 green", "signal_passed 2 green", "signal_passed 3 green", "arrived 4"},
+			},
+		},
+		"following train waits at red": {
+			plan:       domain.MovementPlan{Trains: []domain.Train{train("1A01", 0), train("1A02", 30)}},
+			wantStates: []domain.TrainState{domain.TrainArrived, domain.TrainArrived},
+			wantEvents: map[string][]string{
+				"1A01": {"departed 1", "signal_passed 1 green", "signal_passed 2 green", "signal_passed 3 green", "arrived 4"},
+				"1A02": {"stopped_at_red 1", "departed 1", "signal_passed 1 yellow", "signal_passed 2 yellow", "signal_passed 3 green", "arrived 4"},
+			},
+		},
+		"out of time": {
+			plan:       domain.MovementPlan{Duration: 60, Trains: []domain.Train{train("1A01", 0), train("1A02", 120)}},
+			wantStates: []domain.TrainState{domain.TrainRunning, domain.TrainWaiting},
+			wantEvents: map[string][]string{
+				"1A01": {"departed 1", "signal_passed 1 green"},
+			},
+		},
+		"invalid trains": {
+			plan:    domain.MovementPlan{Trains: []domain.Train{train("1A01", 0), {ID: "1A01", From: "A", To: "A"}}},
+			wantErr: domain.ErrValidation,
+		},
+		"distance across ELRs is unknown": {
+			plan:    domain.MovementPlan{Trains: []domain.Train{{ID: "1A01", From: "B", To: "D", Length: 220, MaxSpeed: 100, Acceleration: 0.5, Braking: 0.7}}},
+			wantErr: domain.ErrValidation,
+		},
+		"no path": {
+			plan:    domain.MovementPlan{Trains: []domain.Train{{ID: "1A01", From: "A", To: "Z", Length: 220, MaxSpeed: 100, Acceleration: 0.5, Braking: 0.7}}},
+			wantErr: application.ErrNoPath,
+		},
+	}
+
+	ctx := context.Background()
+	s := newTestService()
+	require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(network), application.DefaultLoadPolicies, nil), "loading network")
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			log, err := s.SimulateMovements(ctx, test.plan)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "simulating movements")
+				return
+			}
+			require.NoError(t, err, "simulating movements")
+
+			states := make([]domain.TrainState, 0, len(log.Trains))
+			for _, train := range log.Trains {
+				states = append(states, train.State)
+			}
+			assert.Equal(t, test.wantStates, states, "train states")
+
+			events := make(map[string][]string)
+			var previous float64
+			for _, event := range log.Events {
+				assert.GreaterOrEqual(t, event.Time, previous, "events in time order")
+				previous = event.Time
+				if event.Train == "" {
+					continue
+				}
+
+				description := string(event.Type) + " " + strconv.Itoa(event.SignalID)
+				if event.Type == domain.EventSignalPassed {
+					description += " " + string(event.Aspect)
+				}
+				events[event.Train] = append(events[event.Train], description)
+			}
+			assert.Equal(t, test.wantEvents, events, "train events")
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// NextSignals finds the signal after the signal on the track in the direction of travel.
+// When the signal is the last on the track and connected is set, the search carries on from the end of the track
+// onto the tracks joined to it, finding the first signal along each of them. A junction can give several signals,
+// they are ordered by track ID. Nothing is found at the end of the line.
+func (s *Service) NextSignals(ctx context.Context, trackID, signalID int, direction domain.Direction, connected bool) ([]domain.AdjacentSignal, error) {
+	track, err := s.GetTrackSignals(ctx, trackID, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(track.Signals, func(signal domain.TrackSignal) bool { return signal.ID == signalID })
+	if i < 0 {
+		return nil, &domain.NotFoundError{Entity: domain.EntityMileage, Key: fmt.Sprintf("of signal %d on track %d", signalID, trackID)}
+	}
+	from := track.Signals[i]
+
+	if i+1 < len(track.Signals) {
+		return []domain.AdjacentSignal{adjacentSignal(from, trackID, track.Signals[i+1], direction)}, nil
+	}
+	if !connected {
+		return []domain.AdjacentSignal{}, nil
+	}
+
+	network, err := s.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+	end := track.Target
+	if direction == domain.DirectionUp {
+		end = track.Source
+	}
+
+	signals := []domain.AdjacentSignal{}
+	for _, next := range network.firstSignalsFrom(end, trackID) {
+		signals = append(signals, adjacentSignal(from, next.TrackID, next.TrackSignal, next.direction))
+	}
+	slices.SortFunc(signals, func(a, b domain.AdjacentSignal) int {
+		return cmp.Compare(a.TrackID, b.TrackID)
+	})
+
+	return signals, nil
+}
+
+// PreviousSignals finds the signal before the signal on the track in the direction of travel, it is the next signal
+// looking the other way. Direction is still the direction of travel on each signal's track.
+func (s *Service) PreviousSignals(ctx context.Context, trackID, signalID int, direction domain.Direction, connected bool) ([]domain.AdjacentSignal, error) {
+	signals, err := s.NextSignals(ctx, trackID, signalID, direction.Reverse(), connected)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range signals {
+		signals[i].Direction = signals[i].Direction.Reverse()
+	}
+	return signals, nil
+}
+
+// adjacentSignal describes the signal next to from.
+func adjacentSignal(from domain.TrackSignal, trackID int, to domain.TrackSignal, direction domain.Direction) domain.AdjacentSignal {
+	return domain.AdjacentSignal{TrackID: trackID, TrackSignal: to, Direction: direction, Distance: signalDistance(from, to)}
+}
+
+// signalDistance is the distance between two signals, it is only known when both are measured along the same ELR.
+func signalDistance(from, to domain.TrackSignal) *domain.Distance {
+	if from.ELR != to.ELR || from.Mileage == nil || to.Mileage == nil {
+		return nil
+	}
+
+	distance := *to.Mileage - *from.Mileage
+	if distance < 0 {
+		distance = -distance
+	}
+	return &distance
+}
+
+// signalOnTrack is a signal reached by travelling along a track in a direction.
+type signalOnTrack struct {
+	domain.PathSignal
+	direction domain.Direction
+}
+
+// firstSignalsFrom finds the first signal along every track leaving the location other than the track arrived on,
+// that the points at the location let a train onto. Tracks without signals are travelled through to the tracks
+// beyond them, each track is only travelled once.
+func (n *Network) firstSignalsFrom(location string, arrivedOn int) []signalOnTrack {
+	visited := map[int]bool{arrivedOn: true}
+
+	var found []signalOnTrack
+	var travel func(location string, arrivedOn int)
+	travel = func(location string, arrivedOn int) {
+		for _, e := range n.adjacent[location] {
+			if visited[e.trackID] || !n.canPass(location, arrivedOn, e.trackID) {
+				continue
+			}
+			visited[e.trackID] = true
+
+			track := n.tracks[e.trackID]
+			if len(track.Signals) == 0 {
+				travel(e.to, e.trackID)
+				continue
+			}
+
+			// Tracks are travelled down from Source to Target, in increasing mileage, unless they are reversed.
+			signal, direction := track.Signals[0], domain.DirectionDown
+			if e.reversed {
+				signal, direction = track.Signals[len(track.Signals)-1], domain.DirectionUp
+			}
+			found = append(found, signalOnTrack{
+				PathSignal: domain.PathSignal{TrackID: track.ID, TrackSignal: signal},
+				direction:  direction,
+			})
+		}
+	}
+	travel(location, arrivedOn)
+
+	return found
+}
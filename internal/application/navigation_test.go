@@ -0,0 +1,126 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestAdjacentSignals(t *testing.T) {
+	// A-B-C-E-F with D joining at B, track 3 runs from D to B and track 4 has no signals.
+	network := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(1)},
+			{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+		}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: miles(2.5)},
+			{ID: 4, Name: "SIG4", ELR: "ABC", Mileage: miles(3)},
+		}},
+		{ID: 3, Source: "D", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 5, Name: "SIG5", ELR: "XYZ", Mileage: miles(10)},
+			{ID: 6, Name: "SIG6", ELR: "XYZ", Mileage: miles(11)},
+		}},
+		{ID: 4, Source: "C", Target: "E"},
+		{ID: 5, Source: "E", Target: "F", Signals: []domain.TrackSignal{
+			{ID: 7, Name: "SIG7", ELR: "ABC", Mileage: miles(5)},
+		}},
+	}
+	distance := func(v float64) *domain.Distance { return miles(v) }
+
+	tests := map[string]struct {
+		trackID   int
+		signalID  int
+		direction domain.Direction
+		previous  bool
+		connected bool
+		points    []domain.Point
+
+		want    []domain.AdjacentSignal
+		wantErr error
+	}{
+		"next on the same track": {
+			trackID: 1, signalID: 1, direction: domain.DirectionDown,
+			want: []domain.AdjacentSignal{
+				{TrackID: 1, TrackSignal: network[0].Signals[1], Direction: domain.DirectionDown, Distance: distance(1)},
+			},
+		},
+		"previous on the same track travelling up": {
+			trackID: 1, signalID: 1, direction: domain.DirectionUp, previous: true,
+			want: []domain.AdjacentSignal{
+				{TrackID: 1, TrackSignal: network[0].Signals[1], Direction: domain.DirectionUp, Distance: distance(1)},
+			},
+		},
+		"end of the track": {
+			trackID: 1, signalID: 2, direction: domain.DirectionDown,
+			want: []domain.AdjacentSignal{},
+		},
+		"every way out of a junction": {
+			trackID: 1, signalID: 2, direction: domain.DirectionDown, connected: true,
+			want: []domain.AdjacentSignal{
+				{TrackID: 2, TrackSignal: network[1].Signals[0], Direction: domain.DirectionDown, Distance: distance(0.5)},
+				{TrackID: 3, TrackSignal: network[2].Signals[1], Direction: domain.DirectionUp},
+			},
+		},
+		"junction points set normal": {
+			trackID: 1, signalID: 2, direction: domain.DirectionDown, connected: true,
+			points: []domain.Point{{ID: 1, Location: "B", NormalTrackID: 2, ReverseTrackID: 3, Position: domain.PointNormal}},
+			want: []domain.AdjacentSignal{
+				{TrackID: 2, TrackSignal: network[1].Signals[0], Direction: domain.DirectionDown, Distance: distance(0.5)},
+			},
+		},
+		"junction points set reverse": {
+			trackID: 1, signalID: 2, direction: domain.DirectionDown, connected: true,
+			points: []domain.Point{{ID: 1, Location: "B", NormalTrackID: 2, ReverseTrackID: 3, Position: domain.PointReverse}},
+			want: []domain.AdjacentSignal{
+				{TrackID: 3, TrackSignal: network[2].Signals[1], Direction: domain.DirectionUp},
+			},
+		},
+		"previous across a junction": {
+			trackID: 2, signalID: 3, direction: domain.DirectionDown, previous: true, connected: true,
+			want: []domain.AdjacentSignal{
+				{TrackID: 1, TrackSignal: network[0].Signals[1], Direction: domain.DirectionDown, Distance: distance(0.5)},
+				{TrackID: 3, TrackSignal: network[2].Signals[1], Direction: domain.DirectionDown},
+			},
+		},
+		"through a track without signals": {
+			trackID: 2, signalID: 4, direction: domain.DirectionDown, connected: true,
+			want: []domain.AdjacentSignal{
+				{TrackID: 5, TrackSignal: network[4].Signals[0], Direction: domain.DirectionDown, Distance: distance(2)},
+			},
+		},
+		"signal not on the track": {
+			trackID: 1, signalID: 3, direction: domain.DirectionDown,
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(network), application.DefaultLoadPolicies, nil), "loading network")
+			for _, point := range test.points {
+				require.NoError(t, s.CreatePoint(ctx, &point), "creating point")
+			}
+
+			find := s.NextSignals
+			if test.previous {
+				find = s.PreviousSignals
+			}
+			signals, err := find(ctx, test.trackID, test.signalID, test.direction, test.connected)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "finding adjacent signals")
+				return
+			}
+
+			require.NoError(t, err, "finding adjacent signals")
+			assert.Equal(t, test.want, signals, "adjacent signals")
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package application
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// ErrNoPath is returned when two locations are not connected by any tracks.
+var ErrNoPath = errors.New("no path between locations")
+
+// PathWeight decides what the shortest path minimises.
+type PathWeight string
+
+const (
+	// WeightHops minimises the number of tracks travelled.
+	WeightHops PathWeight = "hops"
+	// WeightLength minimises the distance travelled, using the track lengths taken from the mileages.
+	WeightLength PathWeight = "length"
+)
+
+// networkPageSize is the number of records read from the store at a time when reading the whole network.
+const networkPageSize = 1000
+
+// Network is the track topology, locations are the nodes and tracks are the edges between them.
+// Tracks can be travelled in both directions. Points decide which tracks a train can pass between at a location.
+type Network struct {
+	tracks   map[int]domain.TrackSignals
+	adjacent map[string][]edge
+	// closed holds the tracks at each location that points are not set to.
+	closed map[string]map[int]bool
+}
+
+// edge is a track leaving a location.
+type edge struct {
+	trackID  int
+	to       string
+	reversed bool
+}
+
+// NewNetwork builds the network from tracks and their signals ordered by mileage, and the points between them.
+func NewNetwork(tracks []domain.TrackSignals, points []domain.Point) *Network {
+	n := &Network{
+		tracks:   make(map[int]domain.TrackSignals, len(tracks)),
+		adjacent: make(map[string][]edge),
+		closed:   make(map[string]map[int]bool),
+	}
+
+	for _, t := range tracks {
+		n.tracks[t.ID] = t
+		n.adjacent[t.Source] = append(n.adjacent[t.Source], edge{trackID: t.ID, to: t.Target})
+		n.adjacent[t.Target] = append(n.adjacent[t.Target], edge{trackID: t.ID, to: t.Source, reversed: true})
+	}
+
+	for _, p := range points {
+		if n.closed[p.Location] == nil {
+			n.closed[p.Location] = make(map[int]bool)
+		}
+		n.closed[p.Location][p.ClosedTrackID()] = true
+	}
+
+	return n
+}
+
+// canPass reports whether a train that arrived at the location on one track can leave on another.
+// A train that hasn't arrived on a track is starting from the location and doesn't pass the points there.
+func (n *Network) canPass(location string, arrivedOn, leaveOn int) bool {
+	closed := n.closed[location]
+	return arrivedOn == 0 || !closed[arrivedOn] && !closed[leaveOn]
+}
+
+// Network builds the network from every track and point in the store.
+func (s *Service) Network(ctx context.Context) (*Network, error) {
+	tracks, err := s.allTrackSignals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.allPoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNetwork(tracks, points), nil
+}
+
+// allTrackSignals reads every track with its signals from the store.
+func (s *Service) allTrackSignals(ctx context.Context) ([]domain.TrackSignals, error) {
+	var tracks []domain.TrackSignals
+	for page := 0; ; page++ {
+		trackPage, count, err := s.tracks(ctx).ListTrackSignals(ctx, networkPageSize, page)
+		if err != nil {
+			return nil, fmt.Errorf("listing track signals: %w", err)
+		}
+		tracks = append(tracks, trackPage...)
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+
+	return tracks, nil
+}
+
+// allPoints reads every point from the store.
+func (s *Service) allPoints(ctx context.Context) ([]domain.Point, error) {
+	var points []domain.Point
+	for page := 0; ; page++ {
+		pointPage, count, err := s.points(ctx).ListPoints(ctx, "", networkPageSize, page)
+		if err != nil {
+			return nil, fmt.Errorf("listing points: %w", err)
+		}
+		points = append(points, pointPage...)
+
+		if nextPage(networkPageSize, page, count) == 0 {
+			break
+		}
+	}
+
+	return points, nil
+}
+
+// FindPath returns the shortest path of tracks between two locations.
+func (s *Service) FindPath(ctx context.Context, from, to string, weight PathWeight) (*domain.Path, error) {
+	network, err := s.Network(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return network.ShortestPath(from, to, weight)
+}
+
+// TrackLength is the distance between the first and last signal on the track.
+// Tracks with less than two signals have no known length.
+func TrackLength(track domain.TrackSignals) domain.Distance {
+	if len(track.Signals) < 2 {
+		return 0
+	}
+
+	minimum, maximum := *track.Signals[0].Mileage, *track.Signals[0].Mileage
+	for _, s := range track.Signals[1:] {
+		minimum = min(minimum, *s.Mileage)
+		maximum = max(maximum, *s.Mileage)
+	}
+
+	return maximum - minimum
+}
+
+// ShortestPath finds the shortest path between two locations using Dijkstra's algorithm.
+// The path only passes through a location between tracks the points there allow, and never doubles back along a track.
+func (n *Network) ShortestPath(from, to string, weight PathWeight) (*domain.Path, error) {
+	if weight == "" {
+		weight = WeightHops
+	}
+	if weight != WeightHops && weight != WeightLength {
+		return nil, fmt.Errorf("unknown path weight %q", weight)
+	}
+
+	for _, location := range []string{from, to} {
+		if _, ok := n.adjacent[location]; !ok {
+			return nil, fmt.Errorf("location %q is not on the network: %w", location, ErrNoPath)
+		}
+	}
+
+	// The same location can be reached on different tracks, and the points there decide where each can go next.
+	start := pathState{location: from}
+	cost := map[pathState]pathCost{start: {}}
+	previous := make(map[pathState]pathState)
+	visited := make(map[pathState]bool)
+
+	arrived, found := start, false
+	queue := &locationQueue{{pathState: start}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(queuedLocation)
+		if visited[current.pathState] {
+			continue
+		}
+		visited[current.pathState] = true
+
+		if current.location == to {
+			arrived, found = current.pathState, true
+			break
+		}
+
+		for _, e := range n.adjacent[current.location] {
+			if e.trackID == current.arrivedOn || !n.canPass(current.location, current.arrivedOn, e.trackID) {
+				continue
+			}
+
+			state := pathState{location: e.to, arrivedOn: e.trackID}
+			if visited[state] {
+				continue
+			}
+
+			next := current.cost.add(n.edgeCost(e, weight))
+			if c, ok := cost[state]; ok && !next.less(c) {
+				continue
+			}
+			cost[state] = next
+			previous[state] = current.pathState
+			heap.Push(queue, queuedLocation{pathState: state, cost: next})
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("from %q to %q: %w", from, to, ErrNoPath)
+	}
+
+	// Walk back from the destination to recover the tracks travelled.
+	var edges []edge
+	for state := arrived; state != start; state = previous[state] {
+		track := n.tracks[state.arrivedOn]
+		edges = append(edges, edge{trackID: track.ID, to: state.location, reversed: state.location == track.Source})
+	}
+
+	path := &domain.Path{
+		From:    from,
+		To:      to,
+		Tracks:  make([]domain.PathTrack, 0, len(edges)),
+		Signals: []domain.PathSignal{},
+	}
+	for i := len(edges) - 1; i >= 0; i-- {
+		e := edges[i]
+		track := n.tracks[e.trackID]
+
+		path.Hops++
+		path.Length += TrackLength(track)
+		path.Tracks = append(path.Tracks, domain.PathTrack{
+			Track:    domain.Track{ID: track.ID, Source: track.Source, Target: track.Target},
+			Reversed: e.reversed,
+		})
+
+		for j := range track.Signals {
+			signal := track.Signals[j]
+			if e.reversed {
+				signal = track.Signals[len(track.Signals)-1-j]
+			}
+			path.Signals = append(path.Signals, domain.PathSignal{TrackID: track.ID, TrackSignal: signal})
+		}
+	}
+
+	return path, nil
+}
+
+func (n *Network) edgeCost(e edge, weight PathWeight) pathCost {
+	if weight != WeightLength {
+		return pathCost{hops: 1}
+	}
+
+	track := n.tracks[e.trackID]
+	if len(track.Signals) < 2 {
+		return pathCost{unmeasured: 1, hops: 1}
+	}
+	return pathCost{length: TrackLength(track), hops: 1}
+}
+
+// pathCost is the cost of reaching a location, compared field by field.
+// A track with no known length counts as infinitely long, so a length-weighted path only crosses one
+// when there is no measured way round. Paths of equal length are told apart by their hop count.
+type pathCost struct {
+	unmeasured int
+	length     domain.Distance
+	hops       int
+}
+
+func (c pathCost) add(o pathCost) pathCost {
+	return pathCost{unmeasured: c.unmeasured + o.unmeasured, length: c.length + o.length, hops: c.hops + o.hops}
+}
+
+func (c pathCost) less(o pathCost) bool {
+	if c.unmeasured != o.unmeasured {
+		return c.unmeasured < o.unmeasured
+	}
+	if c.length != o.length {
+		return c.length < o.length
+	}
+	return c.hops < o.hops
+}
+
+// pathState is a location reached along a track, the track is 0 at the start of the path.
+type pathState struct {
+	location  string
+	arrivedOn int
+}
+
+type queuedLocation struct {
+	pathState
+	cost pathCost
+}
+
+// locationQueue is a min-heap of locations ordered by their cost from the start.
+type locationQueue []queuedLocation
+
+func (q locationQueue) Len() int           { return len(q) }
+func (q locationQueue) Less(i, j int) bool { return q[i].cost.less(q[j].cost) }
+func (q locationQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *locationQueue) Push(x any)        { *q = append(*q, x.(queuedLocation)) }
+func (q *locationQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,167 @@
+package application_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// testNetwork has a direct but long track from A to C, and a shorter way round through B, both carrying on to F.
+//
+//	A --1--> B --2--> C --5--> F
+//	A --------3-----> C
+//	D --4--> E
+func testNetwork(points []domain.Point) *application.Network {
+	return application.NewNetwork([]domain.TrackSignals{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 10, Mileage: miles(0.5)}, {ID: 11, Mileage: miles(1.5)},
+		}},
+		{ID: 2, Source: "C", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 20, Mileage: miles(2)}, {ID: 21, Mileage: miles(2.5)}, {ID: 22, Mileage: miles(3)},
+		}},
+		{ID: 3, Source: "A", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 30, Mileage: miles(0)}, {ID: 31, Mileage: miles(10)},
+		}},
+		{ID: 4, Source: "D", Target: "E"},
+		{ID: 5, Source: "C", Target: "F"},
+	}, points)
+}
+
+func TestShortestPath(t *testing.T) {
+	tests := map[string]struct {
+		from, to string
+		weight   application.PathWeight
+		points   []domain.Point
+
+		wantTracks    []int
+		wantReversed  []bool
+		wantSignalIDs []int
+		wantLength    domain.Distance
+		wantErr       error
+	}{
+		"fewest hops takes the direct track": {
+			from: "A", to: "C", weight: application.WeightHops,
+			wantTracks:    []int{3},
+			wantReversed:  []bool{false},
+			wantSignalIDs: []int{30, 31},
+			wantLength:    domain.Miles(10),
+		},
+		"shortest length goes round and reverses track 2": {
+			from: "A", to: "C", weight: application.WeightLength,
+			wantTracks:    []int{1, 2},
+			wantReversed:  []bool{false, true},
+			wantSignalIDs: []int{10, 11, 22, 21, 20},
+			wantLength:    domain.Miles(2),
+		},
+		"shortest length crosses an unmeasured track when there is no other way": {
+			from: "D", to: "E", weight: application.WeightLength,
+			wantTracks:    []int{4},
+			wantReversed:  []bool{false},
+			wantSignalIDs: []int{},
+		},
+		"travelling against every track": {
+			from: "C", to: "A", weight: application.WeightLength,
+			wantTracks:    []int{2, 1},
+			wantReversed:  []bool{false, true},
+			wantSignalIDs: []int{20, 21, 22, 11, 10},
+			wantLength:    domain.Miles(2),
+		},
+		"same location": {
+			from: "A", to: "A",
+			wantTracks:    []int{},
+			wantReversed:  []bool{},
+			wantSignalIDs: []int{},
+		},
+		"points set normal keep to the direct track": {
+			from: "A", to: "F", weight: application.WeightLength,
+			points:        []domain.Point{{ID: 1, Location: "C", NormalTrackID: 3, ReverseTrackID: 2, Position: domain.PointNormal}},
+			wantTracks:    []int{3, 5},
+			wantReversed:  []bool{false, false},
+			wantSignalIDs: []int{30, 31},
+			wantLength:    domain.Miles(10),
+		},
+		"points set reverse send the train round": {
+			from: "A", to: "F", weight: application.WeightHops,
+			points:        []domain.Point{{ID: 1, Location: "C", NormalTrackID: 3, ReverseTrackID: 2, Position: domain.PointReverse}},
+			wantTracks:    []int{1, 2, 5},
+			wantReversed:  []bool{false, true, false},
+			wantSignalIDs: []int{10, 11, 22, 21, 20},
+			wantLength:    domain.Miles(2),
+		},
+		"points don't stop a path ending at them": {
+			from: "A", to: "C", weight: application.WeightLength,
+			points:        []domain.Point{{ID: 1, Location: "C", NormalTrackID: 3, ReverseTrackID: 2, Position: domain.PointNormal}},
+			wantTracks:    []int{1, 2},
+			wantReversed:  []bool{false, true},
+			wantSignalIDs: []int{10, 11, 22, 21, 20},
+			wantLength:    domain.Miles(2),
+		},
+		"points set away from the destination": {
+			from: "A", to: "F",
+			points:  []domain.Point{{ID: 1, Location: "C", NormalTrackID: 2, ReverseTrackID: 5, Position: domain.PointNormal}},
+			wantErr: application.ErrNoPath,
+		},
+		"disconnected locations": {
+			from: "A", to: "E",
+			wantErr: application.ErrNoPath,
+		},
+		"unknown location": {
+			from: "A", to: "Z",
+			wantErr: application.ErrNoPath,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := testNetwork(test.points).ShortestPath(test.from, test.to, test.weight)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "shortest path error")
+				return
+			}
+			require.NoError(t, err, "finding shortest path")
+
+			tracks, reversed := []int{}, []bool{}
+			for _, pt := range path.Tracks {
+				tracks = append(tracks, pt.ID)
+				reversed = append(reversed, pt.Reversed)
+			}
+			signalIDs := []int{}
+			for _, ps := range path.Signals {
+				signalIDs = append(signalIDs, ps.ID)
+			}
+
+			assert.Equal(t, test.wantTracks, tracks, "path tracks")
+			assert.Equal(t, test.wantReversed, reversed, "path reversed tracks")
+			assert.Equal(t, test.wantSignalIDs, signalIDs, "path signals")
+			assert.Equal(t, len(test.wantTracks), path.Hops, "path hops")
+			assert.Equal(t, test.wantLength, path.Length, "path length")
+		})
+	}
+}
+
+func TestShortestPathAvoidsUnmeasuredTracks(t *testing.T) {
+	// The direct track from A to B has a single signal so its length isn't known, the way round through C is measured.
+	network := application.NewNetwork([]domain.TrackSignals{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{{ID: 10, Mileage: miles(0.5)}}},
+		{ID: 2, Source: "A", Target: "C", Signals: []domain.TrackSignal{{ID: 20, Mileage: miles(0)}, {ID: 21, Mileage: miles(20)}}},
+		{ID: 3, Source: "C", Target: "B", Signals: []domain.TrackSignal{{ID: 30, Mileage: miles(20)}, {ID: 31, Mileage: miles(40)}}},
+	}, nil)
+
+	path, err := network.ShortestPath("A", "B", application.WeightLength)
+	require.NoError(t, err, "finding shortest path")
+	tracks := []int{}
+	for _, pt := range path.Tracks {
+		tracks = append(tracks, pt.ID)
+	}
+	assert.Equal(t, []int{2, 3}, tracks, "path tracks")
+	assert.Equal(t, domain.Miles(40), path.Length, "path length")
+}
+
+func miles(v float64) *domain.Distance {
+	d := domain.Miles(v)
+	return &d
+}
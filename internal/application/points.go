@@ -0,0 +1,143 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// CreatePoint stores a new point, set to normal unless a position is given.
+// Both its tracks must start or end at its location and neither may be switched by another point there,
+// and no set route may run through its location.
+func (s *Service) CreatePoint(ctx context.Context, point *domain.Point) error {
+	var v validation
+	v.point(*point)
+	if err := v.err(); err != nil {
+		return err
+	}
+	if point.Position == "" {
+		point.Position = domain.PointNormal
+	}
+
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		if err := s.checkPointLayout(ctx, *point); err != nil {
+			return err
+		}
+		if err := s.lockPoint(ctx, point.ID, point.Location); err != nil {
+			return err
+		}
+
+		return s.points(ctx).CreatePoint(ctx, point)
+	})
+}
+
+func (s *Service) GetPoint(ctx context.Context, pointID int) (*domain.Point, error) {
+	return s.points(ctx).GetPoint(ctx, pointID)
+}
+
+// ListPoints lists the points at the location, every point when the location is empty.
+func (s *Service) ListPoints(ctx context.Context, location string, limit, page int) ([]domain.Point, int, error) {
+	points, count, err := s.points(ctx).ListPoints(ctx, location, limit, page)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return points, nextPage(limit, page, count), nil
+}
+
+// UpdatePoint replaces the point with the ID from the path, this is how a point is switched.
+// A missing ID in the body is taken from the path, a different one is a validation error.
+// A point can't be changed while a set route runs through its location.
+func (s *Service) UpdatePoint(ctx context.Context, pointID int, point *domain.Point) error {
+	var v validation
+	matchPathKey(&v, "id", pointID, &point.ID)
+	v.point(*point)
+	if point.Position == "" {
+		v.check("position", "is required")
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.points(ctx).GetPoint(ctx, pointID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkPointLayout(ctx, *point); err != nil {
+			return err
+		}
+		if err := s.lockPoint(ctx, pointID, stored.Location, point.Location); err != nil {
+			return err
+		}
+
+		return s.points(ctx).UpdatePoint(ctx, point)
+	})
+}
+
+// DeletePoint removes a point, it fails while a set route runs through its location
+// and returns domain.ErrNotFound if the point doesn't exist.
+func (s *Service) DeletePoint(ctx context.Context, pointID int) error {
+	return s.inTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.points(ctx).GetPoint(ctx, pointID)
+		if err != nil {
+			return err
+		}
+		if err := s.lockPoint(ctx, pointID, stored.Location); err != nil {
+			return err
+		}
+
+		return s.points(ctx).DeletePoint(ctx, pointID)
+	})
+}
+
+// lockPoint locks the routes so none can be set while the point changes,
+// and fails when a set route already runs through any of its locations.
+func (s *Service) lockPoint(ctx context.Context, pointID int, locations ...string) error {
+	if err := s.routes(ctx).LockRoutes(ctx); err != nil {
+		return err
+	}
+
+	return s.checkPointFree(ctx, pointID, locations...)
+}
+
+// checkPointLayout checks the point against the network: each of its tracks must start or end at its location,
+// and must not already be switched by another point at the location.
+func (s *Service) checkPointLayout(ctx context.Context, point domain.Point) error {
+	branches := []struct {
+		field   string
+		trackID int
+	}{
+		{"normal_track_id", point.NormalTrackID},
+		{"reverse_track_id", point.ReverseTrackID},
+	}
+
+	var v validation
+	for _, branch := range branches {
+		track, err := s.tracks(ctx).GetTrack(ctx, branch.trackID)
+		if err != nil {
+			return err
+		}
+		if track.Source != point.Location && track.Target != point.Location {
+			v.check(branch.field, fmt.Sprintf("is not a track to or from %s", point.Location))
+		}
+	}
+
+	others, _, err := s.points(ctx).ListPoints(ctx, point.Location, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID == point.ID {
+			continue
+		}
+		for _, branch := range branches {
+			if branch.trackID == other.NormalTrackID || branch.trackID == other.ReverseTrackID {
+				v.check(branch.field, fmt.Sprintf("is already switched by point %d", other.ID))
+			}
+		}
+	}
+
+	return v.err()
+}
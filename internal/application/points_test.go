@@ -0,0 +1,93 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestCreatePoint(t *testing.T) {
+	// Tracks 2 and 3 leave B for C and D, track 4 joins C to D.
+	network := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B"},
+		{ID: 2, Source: "B", Target: "C"},
+		{ID: 3, Source: "B", Target: "D"},
+		{ID: 4, Source: "C", Target: "D"},
+	}
+	existing := domain.Point{ID: 1, Location: "B", NormalTrackID: 2, ReverseTrackID: 3}
+
+	tests := map[string]struct {
+		point domain.Point
+
+		wantPosition domain.PointPosition
+		wantFields   []string
+		wantErr      error
+	}{
+		"set normal by default": {
+			point:        domain.Point{ID: 2, Location: "C", NormalTrackID: 2, ReverseTrackID: 4},
+			wantPosition: domain.PointNormal,
+		},
+		"set reverse": {
+			point:        domain.Point{ID: 2, Location: "C", NormalTrackID: 2, ReverseTrackID: 4, Position: domain.PointReverse},
+			wantPosition: domain.PointReverse,
+		},
+		"missing fields": {
+			point:      domain.Point{Position: "sideways"},
+			wantFields: []string{"id", "location", "normal_track_id", "reverse_track_id", "position"},
+			wantErr:    domain.ErrValidation,
+		},
+		"track away from the location": {
+			point:      domain.Point{ID: 2, Location: "C", NormalTrackID: 2, ReverseTrackID: 3},
+			wantFields: []string{"reverse_track_id"},
+			wantErr:    domain.ErrValidation,
+		},
+		"track switched by another point": {
+			point:      domain.Point{ID: 2, Location: "B", NormalTrackID: 1, ReverseTrackID: 3},
+			wantFields: []string{"reverse_track_id"},
+			wantErr:    domain.ErrValidation,
+		},
+		"missing track": {
+			point:   domain.Point{ID: 2, Location: "C", NormalTrackID: 2, ReverseTrackID: 404},
+			wantErr: domain.ErrNotFound,
+		},
+		"existing point": {
+			point:   domain.Point{ID: 1, Location: "C", NormalTrackID: 2, ReverseTrackID: 4},
+			wantErr: domain.ErrConflict,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(network), application.DefaultLoadPolicies, nil), "loading network")
+			require.NoError(t, s.CreatePoint(ctx, &existing), "creating existing point")
+
+			err := s.CreatePoint(ctx, &test.point)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "creating point")
+
+				var validationErr *domain.ValidationError
+				if errors.As(err, &validationErr) {
+					fields := make([]string, 0, len(validationErr.Fields))
+					for _, field := range validationErr.Fields {
+						fields = append(fields, field.Field)
+					}
+					assert.Equal(t, test.wantFields, fields, "invalid fields")
+				}
+				return
+			}
+			require.NoError(t, err, "creating point")
+
+			got, err := s.GetPoint(ctx, test.point.ID)
+			require.NoError(t, err, "getting point")
+			assert.Equal(t, test.wantPosition, got.Position, "position")
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package application
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
@@ -11,4 +13,93 @@ type Service struct {
 	SignalStore  domain.SignalStore
 	TrackStore   domain.TrackStore
 	MileageStore domain.MileageStore
+	ELRStore     domain.ELRStore
+	RouteStore   domain.RouteStore
+	PointStore   domain.PointStore
+	Transactor   domain.Transactor
+}
+
+// txKey is the context key of the transaction opened by inTransaction.
+type txKey struct{}
+
+// inTransaction runs fn in a unit of work spanning every store.
+// Service calls made with the context passed to fn take part in the same transaction,
+// and calls to inTransaction while one is already open join it.
+func (s *Service) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return fn(ctx)
+	}
+
+	return s.Transactor.InTransaction(ctx, func(ctx context.Context, tx domain.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// inSnapshot runs fn against a read-only view of every store that doesn't change while fn runs.
+// Service calls made with the context passed to fn read from the view, and calls to inSnapshot
+// while a transaction is already open join it.
+func (s *Service) inSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return fn(ctx)
+	}
+
+	return s.Transactor.InSnapshot(ctx, func(ctx context.Context, tx domain.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// signals returns the signal store of the open transaction, if any.
+func (s *Service) signals(ctx context.Context) domain.SignalStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.SignalStore
+}
+
+// tracks returns the track store of the open transaction, if any.
+func (s *Service) tracks(ctx context.Context) domain.TrackStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.TrackStore
+}
+
+// mileages returns the mileage store of the open transaction, if any.
+func (s *Service) mileages(ctx context.Context) domain.MileageStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.MileageStore
+}
+
+// elrs returns the ELR store of the open transaction, if any.
+func (s *Service) elrs(ctx context.Context) domain.ELRStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.ELRStore
+}
+
+// routes returns the route store of the open transaction, if any.
+func (s *Service) routes(ctx context.Context) domain.RouteStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.RouteStore
+}
+
+// points returns the point store of the open transaction, if any.
+func (s *Service) points(ctx context.Context) domain.PointStore {
+	if tx, ok := ctx.Value(txKey{}).(domain.Tx); ok {
+		return tx
+	}
+	return s.PointStore
+}
+
+// nextPage returns the page following the given one, or 0 when it is the last page.
+func nextPage(limit, page, count int) int {
+	if limit > 0 && (page+1)*limit < count {
+		return page + 1
+	}
+	return 0
 }
@@ -6,32 +6,59 @@ import (
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
-func (s *Service) CreateSignal(ctx context.Context, signal *domain.Signal) error {
-	return s.SignalStore.CreateSignal(ctx, signal)
+// CreateSignal stores the signal, the policy decides what happens when the signal already exists.
+// The signal's ELR must be registered.
+func (s *Service) CreateSignal(ctx context.Context, signal *domain.Signal, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	var v validation
+	v.signal(*signal)
+	if err := s.checkRegistered(ctx, &v, map[string]bool{}, "elr", signal.ELR); err != nil {
+		return "", err
+	}
+	if err := v.err(); err != nil {
+		return "", err
+	}
+
+	return s.signals(ctx).CreateSignal(ctx, signal, policy)
 }
 
 func (s *Service) GetSignal(ctx context.Context, signalID int) (*domain.Signal, error) {
-	return s.SignalStore.GetSignal(ctx, signalID)
+	return s.signals(ctx).GetSignal(ctx, signalID)
 }
 
-func (s *Service) ListSignals(ctx context.Context, limit, page int) (signals []domain.Signal, nextPage int, err error) {
-	// TODO: validate limit and page
-	signals, count, err := s.SignalStore.ListSignals(ctx, limit, page)
-	if err != nil {
+// ListSignals lists the signals with every attribute set in the filter.
+func (s *Service) ListSignals(ctx context.Context, filter domain.SignalFilter, limit, page int) ([]domain.Signal, int, error) {
+	var v validation
+	v.signalFilter(filter)
+	if err := v.err(); err != nil {
 		return nil, 0, err
 	}
 
-	if page*limit < count {
-		nextPage = page + 1
+	// TODO: validate limit and page
+	signals, count, err := s.signals(ctx).ListSignals(ctx, filter, limit, page)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return signals, nextPage, nil
+	return signals, nextPage(limit, page, count), nil
 }
 
-func (s *Service) UpdateSignal(ctx context.Context, signal *domain.Signal) error {
-	return s.SignalStore.UpdateSignal(ctx, signal)
+// UpdateSignal replaces the signal with the ID from the path.
+// A missing ID in the body is taken from the path, a different one is a validation error.
+// The signal's ELR must be registered.
+func (s *Service) UpdateSignal(ctx context.Context, signalID int, signal *domain.Signal) error {
+	var v validation
+	matchPathKey(&v, "id", signalID, &signal.ID)
+	v.signal(*signal)
+	if err := s.checkRegistered(ctx, &v, map[string]bool{}, "elr", signal.ELR); err != nil {
+		return err
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	return s.signals(ctx).UpdateSignal(ctx, signal)
 }
 
 func (s *Service) DeleteSignal(ctx context.Context, signalID int) error {
-	return s.SignalStore.DeleteSignal(ctx, signalID)
+	return s.signals(ctx).DeleteSignal(ctx, signalID)
 }
@@ -0,0 +1,98 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// SimulateAspects works out the aspect of every signal on the track in the direction of travel,
+// with the given block sections occupied. Each occupied section is given by its entry signal.
+// The line beyond the last signal on the track is taken to be clear.
+func (s *Service) SimulateAspects(ctx context.Context, trackID int, direction domain.Direction, occupancy domain.Occupancy) (*domain.AspectSimulation, error) {
+	track, err := s.GetTrackSignals(ctx, trackID, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[int]bool, len(track.Signals))
+	for _, section := range blockSections(*track, direction) {
+		entries[section.EntrySignal.ID] = true
+	}
+
+	var v validation
+	occupied := make(map[int]bool, len(occupancy.Occupied))
+	for i, signalID := range occupancy.Occupied {
+		if !entries[signalID] {
+			v.check(fmt.Sprintf("occupied[%d]", i), "is not the entry signal of a block section on the track")
+		}
+		occupied[signalID] = true
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(occupied))
+	for _, signal := range track.Signals {
+		if occupied[signal.ID] {
+			ids = append(ids, signal.ID)
+		}
+	}
+
+	return &domain.AspectSimulation{
+		TrackID:   track.ID,
+		Direction: direction,
+		Occupied:  ids,
+		Signals:   simulateAspects(track.Signals, occupied),
+	}, nil
+}
+
+// simulateAspects sequences the aspects of the signals, which are in the order of travel.
+// occupied holds the entry signals of the occupied block sections, the line beyond the last signal is clear.
+//
+// A main signal protects every section up to the next main signal and shows red when any of them
+// is occupied. Otherwise it steps up from the aspect of the next main signal: yellow after red,
+// double yellow after yellow on a 4-aspect signal, and green. A 2-aspect main signal is red or green.
+// A distant signal shows yellow when the main signal ahead of it is red or a train is between them.
+// Other signals are passed over, the sections after them still count towards the main signal behind.
+func simulateAspects(signals []domain.TrackSignal, occupied map[int]bool) []domain.SignalAspect {
+	aspects := make([]domain.SignalAspect, len(signals))
+
+	next, blocked := domain.AspectGreen, false
+	for i := len(signals) - 1; i >= 0; i-- {
+		signal := signals[i]
+		blocked = blocked || occupied[signal.ID]
+
+		aspect := domain.SignalAspect{TrackSignal: signal}
+		switch signal.Type {
+		case domain.SignalMain:
+			aspect.Aspect = mainAspect(signal.Aspects, blocked, next)
+			next, blocked = aspect.Aspect, false
+		case domain.SignalDistant:
+			aspect.Aspect = domain.AspectGreen
+			if blocked || next == domain.AspectRed {
+				aspect.Aspect = domain.AspectYellow
+			}
+		}
+		aspects[i] = aspect
+	}
+
+	return aspects
+}
+
+// mainAspect is the aspect of a main signal with the number of aspects, given whether the line
+// it protects is blocked and the aspect of the next main signal.
+func mainAspect(aspects int, blocked bool, next domain.Aspect) domain.Aspect {
+	switch {
+	case blocked:
+		return domain.AspectRed
+	case aspects == 2:
+		return domain.AspectGreen
+	case next == domain.AspectRed:
+		return domain.AspectYellow
+	case aspects == 4 && next == domain.AspectYellow:
+		return domain.AspectDoubleYellow
+	}
+	return domain.AspectGreen
+}
@@ -0,0 +1,117 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestSimulateAspects(t *testing.T) {
+	kind := func(signalType domain.SignalType, aspects int) domain.SignalKind {
+		return domain.SignalKind{Type: signalType, Aspects: aspects, Automatic: signalType == domain.SignalMain}
+	}
+	main4, main3, main2 := kind(domain.SignalMain, 4), kind(domain.SignalMain, 3), kind(domain.SignalMain, 2)
+	network := domain.TrackSignalSlice{
+		{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+			{ID: 1, ELR: "ABC", Mileage: miles(1), SignalKind: main4},
+			{ID: 2, ELR: "ABC", Mileage: miles(2), SignalKind: main4},
+			{ID: 3, ELR: "ABC", Mileage: miles(3), SignalKind: main4},
+			{ID: 4, ELR: "ABC", Mileage: miles(4), SignalKind: main4},
+			{ID: 5, ELR: "ABC", Mileage: miles(5), SignalKind: main4},
+		}},
+		{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+			{ID: 11, ELR: "ABC", Mileage: miles(6), SignalKind: main3},
+			{ID: 12, ELR: "ABC", Mileage: miles(7), SignalKind: main3},
+			{ID: 13, ELR: "ABC", Mileage: miles(8), SignalKind: main3},
+		}},
+		{ID: 3, Source: "C", Target: "D", Signals: []domain.TrackSignal{
+			{ID: 21, ELR: "ABC", Mileage: miles(9), SignalKind: main2},
+			{ID: 22, ELR: "ABC", Mileage: miles(9.5), SignalKind: kind(domain.SignalDistant, 2)},
+			{ID: 23, ELR: "ABC", Mileage: miles(9.75), SignalKind: kind(domain.SignalShunt, 0)},
+			{ID: 24, ELR: "ABC", Mileage: miles(10), SignalKind: main3},
+			{ID: 25, ELR: "ABC", Mileage: miles(11), SignalKind: main3},
+		}},
+	}
+
+	tests := map[string]struct {
+		trackID   int
+		direction domain.Direction
+		occupied  []int
+
+		wantAspects  []domain.Aspect
+		wantOccupied []int
+		wantErr      error
+	}{
+		"clear 4-aspect line": {
+			trackID: 1, direction: domain.DirectionDown,
+			wantAspects:  []domain.Aspect{"green", "green", "green", "green", "green"},
+			wantOccupied: []int{},
+		},
+		"4-aspect sequence behind a train": {
+			trackID: 1, direction: domain.DirectionDown,
+			occupied:     []int{4},
+			wantAspects:  []domain.Aspect{"green", "double_yellow", "yellow", "red", "green"},
+			wantOccupied: []int{4},
+		},
+		"4-aspect sequence in the up direction": {
+			trackID: 1, direction: domain.DirectionUp,
+			occupied:     []int{2, 4, 2},
+			wantAspects:  []domain.Aspect{"yellow", "red", "yellow", "red", "green"},
+			wantOccupied: []int{4, 2},
+		},
+		"3-aspect sequence": {
+			trackID: 2, direction: domain.DirectionDown,
+			occupied:     []int{12},
+			wantAspects:  []domain.Aspect{"yellow", "red", "green"},
+			wantOccupied: []int{12},
+		},
+		"distant and shunt signals between main signals": {
+			trackID: 3, direction: domain.DirectionDown,
+			occupied:     []int{23},
+			wantAspects:  []domain.Aspect{"red", "yellow", "", "green", "green"},
+			wantOccupied: []int{23},
+		},
+		"distant signal before a red main signal": {
+			trackID: 3, direction: domain.DirectionDown,
+			occupied:     []int{24},
+			wantAspects:  []domain.Aspect{"green", "yellow", "", "red", "green"},
+			wantOccupied: []int{24},
+		},
+		"section beyond the last signal": {
+			trackID: 1, direction: domain.DirectionDown,
+			occupied: []int{5},
+			wantErr:  domain.ErrValidation,
+		},
+		"missing track": {
+			trackID: 404, direction: domain.DirectionDown,
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	ctx := context.Background()
+	s := newTestService()
+	require.NoError(t, s.LoadTrackSignals(ctx, application.SliceSource(network), application.DefaultLoadPolicies, nil), "loading network")
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			simulation, err := s.SimulateAspects(ctx, test.trackID, test.direction, domain.Occupancy{Occupied: test.occupied})
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "simulating aspects")
+				return
+			}
+			require.NoError(t, err, "simulating aspects")
+
+			aspects := make([]domain.Aspect, 0, len(simulation.Signals))
+			for _, signal := range simulation.Signals {
+				aspects = append(aspects, signal.Aspect)
+			}
+			assert.Equal(t, test.wantAspects, aspects, "aspects in the order of travel")
+			assert.Equal(t, test.wantOccupied, simulation.Occupied, "occupied sections")
+		})
+	}
+}
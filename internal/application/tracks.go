@@ -2,50 +2,105 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	"github.com/warrenb95/railway-signals/internal/domain"
 )
 
-func (s *Service) GetSignalTracks(ctx context.Context, signalID, limit, page int) (tracks []domain.Track, nextPage int, err error) {
+func (s *Service) GetSignalTracks(ctx context.Context, signalID, limit, page int) ([]domain.Track, int, error) {
 	// TODO: validate limit and page
-	tracks, count, err := s.TrackStore.ListSignalTracks(ctx, signalID, limit, page)
+	tracks, count, err := s.tracks(ctx).ListSignalTracks(ctx, signalID, limit, page)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	if page*limit < count {
-		nextPage = page + 1
+	return tracks, nextPage(limit, page, count), nil
+}
+
+// CreateTrack stores the track, the policy decides what happens when the track already exists.
+func (s *Service) CreateTrack(ctx context.Context, track *domain.Track, policy domain.ConflictPolicy) (domain.WriteOutcome, error) {
+	var v validation
+	v.track(*track)
+	if err := v.err(); err != nil {
+		return "", err
 	}
 
-	return tracks, nextPage, nil
+	return s.tracks(ctx).CreateTrack(ctx, track, policy)
 }
 
-func (s *Service) CreateTrack(ctx context.Context, track *domain.Track) error {
-	return s.TrackStore.CreateTrack(ctx, track)
+// CreateTrackSignals creates the track with its nested signals and their mileages in a single transaction.
+// The policies decide what happens to the track, signals and mileages that already exist.
+// The ELRs of the signals must be registered.
+// Returns the stored track with its signals ordered by mileage.
+func (s *Service) CreateTrackSignals(ctx context.Context, track *domain.TrackSignals, policies domain.ConflictPolicies) (*domain.TrackSignals, error) {
+	var v validation
+	v.trackSignals(*track)
+	known := make(map[string]bool)
+	for i, signal := range track.Signals {
+		if err := s.checkRegistered(ctx, &v, known, fmt.Sprintf("signal_ids[%d].elr", i), signal.ELR); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	err := s.inTransaction(ctx, func(ctx context.Context) error {
+		var counts domain.LoadProgress
+		return s.writeTrackSignals(ctx, *track, policies, &counts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTrackSignals(ctx, track.ID, domain.DirectionDown)
 }
 
 func (s *Service) GetTrack(ctx context.Context, trackID int) (*domain.Track, error) {
-	return s.TrackStore.GetTrack(ctx, trackID)
+	return s.tracks(ctx).GetTrack(ctx, trackID)
 }
 
-func (s *Service) ListTracks(ctx context.Context, limit, page int) (tracks []domain.Track, nextPage int, err error) {
-	// TODO: validate limit and page
-	signals, count, err := s.TrackStore.ListTracks(ctx, limit, page)
+// GetTrackSignals returns the track with its signals ordered by mileage in the given direction of travel.
+func (s *Service) GetTrackSignals(ctx context.Context, trackID int, direction domain.Direction) (*domain.TrackSignals, error) {
+	track, err := s.tracks(ctx).GetTrackSignals(ctx, trackID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	if page*limit < count {
-		nextPage = page + 1
+	if track.Signals == nil {
+		track.Signals = []domain.TrackSignal{}
+	}
+	if direction == domain.DirectionUp {
+		slices.Reverse(track.Signals)
+	}
+
+	return track, nil
+}
+
+func (s *Service) ListTracks(ctx context.Context, limit, page int) ([]domain.Track, int, error) {
+	// TODO: validate limit and page
+	signals, count, err := s.tracks(ctx).ListTracks(ctx, limit, page)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return signals, nextPage, nil
+	return signals, nextPage(limit, page, count), nil
 }
 
-func (s *Service) UpdateTrack(ctx context.Context, track *domain.Track) error {
-	return s.TrackStore.UpdateTrack(ctx, track)
+// UpdateTrack replaces the track with the ID from the path.
+// A missing ID in the body is taken from the path, a different one is a validation error.
+func (s *Service) UpdateTrack(ctx context.Context, trackID int, track *domain.Track) error {
+	var v validation
+	matchPathKey(&v, "id", trackID, &track.ID)
+	v.track(*track)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	return s.tracks(ctx).UpdateTrack(ctx, track)
 }
 
 func (s *Service) DeleteTrack(ctx context.Context, trackID int) error {
-	return s.TrackStore.DeleteTrack(ctx, trackID)
+	return s.tracks(ctx).DeleteTrack(ctx, trackID)
 }
@@ -0,0 +1,123 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// failOnConflict rejects every write of an existing entity that differs from the stored one.
+var failOnConflict = domain.ConflictPolicies{
+	Tracks:   domain.ConflictFail,
+	Signals:  domain.ConflictFail,
+	Mileages: domain.ConflictFail,
+}
+
+func TestCreateTrackSignals(t *testing.T) {
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		track *domain.TrackSignals
+
+		wantErr error
+	}{
+		"new track and signals": {
+			track: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+				{ID: 3, Name: "SIG3", ELR: "ABC", Mileage: miles(3)},
+			}},
+		},
+		"reuses an existing signal": {
+			track: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(2)},
+			}},
+		},
+		"existing signal with a different name": {
+			track: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+				{ID: 1, Name: "RENAMED", ELR: "ABC", Mileage: miles(2)},
+			}},
+			wantErr: domain.ErrConflict,
+		},
+		"existing track with a different target": {
+			track: &domain.TrackSignals{ID: 1, Source: "A", Target: "Z", Signals: []domain.TrackSignal{
+				{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+			}},
+			wantErr: domain.ErrConflict,
+		},
+		"signal repeated on the track": {
+			track: &domain.TrackSignals{ID: 2, Source: "B", Target: "C", Signals: []domain.TrackSignal{
+				{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(2)},
+				{ID: 2, Name: "SIG2", ELR: "ABC", Mileage: miles(3)},
+			}},
+			wantErr: domain.ErrValidation,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newTestService()
+			_, err := s.CreateSignal(ctx, &domain.Signal{ID: 1, Name: "SIG1", ELR: "ABC"}, domain.ConflictFail)
+			require.NoError(t, err, "creating signal")
+			_, err = s.CreateTrack(ctx, &domain.Track{ID: 1, Source: "A", Target: "B"}, domain.ConflictFail)
+			require.NoError(t, err, "creating track")
+
+			_, err = s.CreateTrackSignals(ctx, test.track, failOnConflict)
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr, "creating track signals")
+
+				// Nothing from the failed request is kept.
+				_, err := s.GetSignal(ctx, 2)
+				require.Error(t, err, "getting signal from failed request")
+				_, err = s.GetTrack(ctx, 2)
+				require.Error(t, err, "getting track from failed request")
+				return
+			}
+			require.NoError(t, err, "creating track signals")
+
+			got, err := s.GetTrackSignals(ctx, test.track.ID, domain.DirectionDown)
+			require.NoError(t, err, "getting track signals")
+			assert.Equal(t, test.track, got, "track signals")
+		})
+	}
+}
+
+func TestListNextPage(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService()
+	for i := 1; i <= 5; i++ {
+		_, err := s.CreateSignal(ctx, &domain.Signal{ID: i, Name: "SIG", ELR: "ABC"}, domain.ConflictFail)
+		require.NoError(t, err, "creating signal")
+		_, err = s.CreateTrack(ctx, &domain.Track{ID: i, Source: "A", Target: "B"}, domain.ConflictFail)
+		require.NoError(t, err, "creating track")
+	}
+
+	tests := map[string]struct {
+		limit, page int
+
+		wantNextPage int
+	}{
+		"first page":        {limit: 2, page: 0, wantNextPage: 1},
+		"middle page":       {limit: 2, page: 1, wantNextPage: 2},
+		"last partial page": {limit: 2, page: 2},
+		"last full page":    {limit: 5, page: 0},
+		"past the end":      {limit: 2, page: 3},
+		"no limit":          {limit: 0, page: 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, nextPage, err := s.ListSignals(ctx, domain.SignalFilter{}, test.limit, test.page)
+			require.NoError(t, err, "listing signals")
+			assert.Equal(t, test.wantNextPage, nextPage, "signal next page")
+
+			_, nextPage, err = s.ListTracks(ctx, test.limit, test.page)
+			require.NoError(t, err, "listing tracks")
+			assert.Equal(t, test.wantNextPage, nextPage, "track next page")
+		})
+	}
+}
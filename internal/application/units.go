@@ -0,0 +1,257 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+var distanceType = reflect.TypeOf(domain.Distance(0))
+
+// MarshalJSONIn encodes v as JSON with every distance in it written in the unit.
+func MarshalJSONIn(v any, unit domain.Unit) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || unit == "" || unit == domain.UnitMiles {
+		return data, err
+	}
+
+	node, err := decodeNode(data)
+	if err != nil {
+		return nil, err
+	}
+	node, err = rewriteDistances(node, reflect.ValueOf(v), func(_ any, distance reflect.Value) (any, error) {
+		data, err := domain.Distance(distance.Int()).MarshalJSONIn(unit)
+		return json.RawMessage(data), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(node)
+}
+
+// UnmarshalJSONIn decodes JSON into v, reading every distance given as a bare number in the unit.
+// Distances given as text such as "12m 34ch" carry their own units.
+func UnmarshalJSONIn(data []byte, v any, unit domain.Unit) error {
+	if unit == "" || unit == domain.UnitMiles {
+		return json.Unmarshal(data, v)
+	}
+
+	node, err := decodeNode(data)
+	if err != nil {
+		return err
+	}
+	node, err = rewriteDistances(node, reflect.ValueOf(v), func(node any, _ reflect.Value) (any, error) {
+		number, ok := node.(json.Number)
+		if !ok {
+			return node, nil
+		}
+
+		var distance domain.Distance
+		if err := distance.UnmarshalJSONIn([]byte(number), unit); err != nil {
+			return nil, err
+		}
+		// Miles and yards are read back exactly, whatever the unit.
+		return distance.Format(domain.UnitMilesYards), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeNode decodes JSON into objects, slices and values, keeping numbers as they were written and
+// fields in the order they were written.
+func decodeNode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = dec.Token()
+		return items, err
+	}
+
+	return tok, nil
+}
+
+// object is a decoded JSON object that is encoded again with its fields in their original order.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// rewriteDistances replaces every distance in node, the decoded JSON of v, with the result of rewrite.
+// Where v holds nothing for part of node, such as an empty slice that node is being decoded into,
+// the zero value of its type is followed instead.
+func rewriteDistances(node any, v reflect.Value, rewrite func(node any, distance reflect.Value) (any, error)) (any, error) {
+	if node == nil || !v.IsValid() {
+		return node, nil
+	}
+	if v.Type() == distanceType {
+		return rewrite(node, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			if v.Kind() == reflect.Interface {
+				return node, nil
+			}
+			return rewriteDistances(node, reflect.Zero(v.Type().Elem()), rewrite)
+		}
+		return rewriteDistances(node, v.Elem(), rewrite)
+
+	case reflect.Slice, reflect.Array:
+		items, ok := node.([]any)
+		if !ok {
+			return node, nil
+		}
+		for i := range items {
+			item := reflect.Zero(v.Type().Elem())
+			if i < v.Len() {
+				item = v.Index(i)
+			}
+
+			var err error
+			if items[i], err = rewriteDistances(items[i], item, rewrite); err != nil {
+				return nil, err
+			}
+		}
+
+	case reflect.Map:
+		obj, ok := node.(object)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return node, nil
+		}
+		for i, m := range obj {
+			item := reflect.Zero(v.Type().Elem())
+			if value := v.MapIndex(reflect.ValueOf(m.key).Convert(v.Type().Key())); value.IsValid() {
+				item = value
+			}
+
+			var err error
+			if obj[i].value, err = rewriteDistances(m.value, item, rewrite); err != nil {
+				return nil, err
+			}
+		}
+
+	case reflect.Struct:
+		if obj, ok := node.(object); ok {
+			return node, rewriteFields(obj, v, rewrite)
+		}
+	}
+
+	return node, nil
+}
+
+// rewriteFields rewrites the distances in the JSON fields of a struct, following its json tags.
+// The fields of embedded structs are promoted as encoding/json does.
+func rewriteFields(obj object, v reflect.Value, rewrite func(node any, distance reflect.Value) (any, error)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					value = reflect.Zero(value.Type().Elem())
+				} else {
+					value = value.Elem()
+				}
+			}
+			if value.Kind() == reflect.Struct {
+				if err := rewriteFields(obj, value, rewrite); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		for j, m := range obj {
+			if m.key != name {
+				continue
+			}
+
+			var err error
+			if obj[j].value, err = rewriteDistances(m.value, value, rewrite); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package application_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/application"
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestMarshalJSONIn(t *testing.T) {
+	tests := map[string]struct {
+		value any
+		unit  domain.Unit
+
+		wantJSON string
+	}{
+		"decimal miles by default": {
+			value:    domain.Mileage{SignalID: 1, TrackID: 2, Mileage: domain.Miles(12.5)},
+			wantJSON: `{"signal_id":1,"track_id":2,"mileage":12.5}`,
+		},
+		"chains": {
+			value:    domain.Mileage{SignalID: 1, TrackID: 2, Mileage: domain.Miles(12.5)},
+			unit:     domain.UnitChains,
+			wantJSON: `{"signal_id":1,"track_id":2,"mileage":1000}`,
+		},
+		"embedded and missing mileages as text": {
+			value: []domain.ELRSignal{
+				{TrackID: 1, TrackSignal: domain.TrackSignal{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(12.425)}},
+				{TrackID: 1, TrackSignal: domain.TrackSignal{ID: 2, Name: "SIG2", ELR: "ABC"}},
+			},
+			unit:     domain.UnitMilesChains,
+			wantJSON: `[{"track_id":1,"signal_id":1,"signal_name":"SIG1","elr":"ABC","mileage":"12m 34ch"},{"track_id":1,"signal_id":2,"signal_name":"SIG2","elr":"ABC","mileage":null}]`,
+		},
+		"inside a map": {
+			value:    map[string]any{"elrs": []domain.ELR{{Code: "ABC", EndMileage: domain.Miles(1)}}, "next_page": 0},
+			unit:     domain.UnitYards,
+			wantJSON: `{"elrs":[{"code":"ABC","description":"","start_mileage":0,"end_mileage":1760,"start_location":"","end_location":""}],"next_page":0}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := application.MarshalJSONIn(test.value, test.unit)
+			require.NoError(t, err, "marshalling")
+			assert.JSONEq(t, test.wantJSON, string(data), "JSON")
+		})
+	}
+}
+
+func TestUnmarshalJSONIn(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		unit  domain.Unit
+
+		want          domain.TrackSignals
+		errorContains string
+	}{
+		"numbers in the unit and text in its own units": {
+			input: `{"track_id": 1, "source": "A", "target": "B", "signal_ids": [
+				{"signal_id": 1, "mileage": 1000},
+				{"signal_id": 2, "mileage": "12m 40ch"},
+				{"signal_id": 3, "mileage": null}
+			]}`,
+			unit: domain.UnitChains,
+			want: domain.TrackSignals{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+				{ID: 1, Mileage: miles(12.5)},
+				{ID: 2, Mileage: miles(12.5)},
+				{ID: 3},
+			}},
+		},
+		"metres": {
+			input: `{"track_id": 1, "signal_ids": [{"signal_id": 1, "mileage": 1609.344}]}`,
+			unit:  domain.UnitMetres,
+			want:  domain.TrackSignals{ID: 1, Signals: []domain.TrackSignal{{ID: 1, Mileage: miles(1)}}},
+		},
+		"invalid text": {
+			input:         `{"track_id": 1, "signal_ids": [{"signal_id": 1, "mileage": "12 furlongs"}]}`,
+			unit:          domain.UnitChains,
+			errorContains: "unknown unit",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got domain.TrackSignals
+			err := application.UnmarshalJSONIn([]byte(test.input), &got, test.unit)
+			if test.errorContains != "" {
+				require.ErrorContains(t, err, test.errorContains, "unmarshal error contains")
+				return
+			}
+
+			require.NoError(t, err, "unmarshalling")
+			assert.Equal(t, test.want, got, "track signals")
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+// maxELRLength and maxNameLength match the column sizes in the database.
+const (
+	maxELRLength  = 4
+	maxNameLength = 255
+)
+
+// elrPattern matches an Engineer's Line Reference, three capital letters optionally followed by a letter or digit.
+var elrPattern = regexp.MustCompile(`^[A-Z]{3}[A-Z0-9]?$`)
+
+// validation collects every rule broken by an entity so they can be reported together.
+type validation struct {
+	fields []domain.FieldError
+}
+
+// check records the problem with the field, an empty problem means the field is valid.
+func (v *validation) check(field, problem string) {
+	if problem != "" {
+		v.fields = append(v.fields, domain.FieldError{Field: field, Reason: problem})
+	}
+}
+
+// matchPathKey fills in a missing body key from the path and reports a body key that names a different entity.
+func matchPathKey[K comparable](v *validation, field string, pathKey K, bodyKey *K) {
+	var zero K
+	if *bodyKey == zero {
+		*bodyKey = pathKey
+		return
+	}
+	if *bodyKey != pathKey {
+		v.check(field, fmt.Sprintf("is %v but the path is for %v", *bodyKey, pathKey))
+	}
+}
+
+// err returns a domain.ValidationError listing every problem, or nil if there were none.
+func (v *validation) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &domain.ValidationError{Fields: v.fields}
+}
+
+func idProblem(id int) string {
+	switch {
+	case id == 0:
+		return "is required"
+	case id < 0:
+		return "must be positive"
+	}
+	return ""
+}
+
+func elrProblem(elr string) string {
+	switch {
+	case elr == "":
+		return "is required"
+	case len(elr) > maxELRLength:
+		return fmt.Sprintf("is longer than %d characters", maxELRLength)
+	case !elrPattern.MatchString(elr):
+		return "must be three capital letters, optionally followed by a letter or digit"
+	}
+	return ""
+}
+
+// nameProblem checks a signal name or location, only required names have to be set.
+func nameProblem(name string, required bool) string {
+	switch {
+	case required && strings.TrimSpace(name) == "":
+		return "is required"
+	case len(name) > maxNameLength:
+		return fmt.Sprintf("is longer than %d characters", maxNameLength)
+	}
+	return ""
+}
+
+func mileageProblem(mileage domain.Distance) string {
+	if mileage < 0 {
+		return "must not be negative"
+	}
+	return ""
+}
+
+// locationsProblem checks that a track joins two different locations.
+func locationsProblem(source, target string) string {
+	if source != "" && source == target {
+		return "must differ from source"
+	}
+	return ""
+}
+
+// signalTypeProblem checks a signal type, an empty type is an unclassified signal.
+func signalTypeProblem(signalType domain.SignalType) string {
+	if signalType == "" {
+		return ""
+	}
+	if _, err := domain.ParseSignalType(string(signalType)); err != nil {
+		return "must be main, distant, shunt, banner_repeater or stop_board"
+	}
+	return ""
+}
+
+func routeIndicatorProblem(indicator domain.RouteIndicator) string {
+	if indicator == "" {
+		return ""
+	}
+	if _, err := domain.ParseRouteIndicator(string(indicator)); err != nil {
+		return "must be junction or theatre"
+	}
+	return ""
+}
+
+// aspectsProblem checks the number of aspects a signal of the type shows.
+// Only colour-light main and distant signals have aspects.
+func aspectsProblem(signalType domain.SignalType, aspects int) string {
+	switch signalType {
+	case domain.SignalMain:
+		if aspects < 2 || aspects > 4 {
+			return "must be 2, 3 or 4 for a main signal"
+		}
+	case domain.SignalDistant:
+		if aspects != 2 {
+			return "must be 2 for a distant signal"
+		}
+	case "":
+		if aspects != 0 {
+			return "requires a type"
+		}
+	default:
+		if aspects != 0 {
+			return fmt.Sprintf("must not be set for a %s signal", signalType)
+		}
+	}
+	return ""
+}
+
+// signalKind checks the attributes of a signal are a combination that exists, the fields are prefixed with prefix.
+func (v *validation) signalKind(prefix string, kind domain.SignalKind) {
+	if problem := signalTypeProblem(kind.Type); problem != "" {
+		v.check(prefix+"type", problem)
+		return
+	}
+	v.check(prefix+"aspects", aspectsProblem(kind.Type, kind.Aspects))
+
+	switch {
+	case kind.RouteIndicator == "":
+	case routeIndicatorProblem(kind.RouteIndicator) != "":
+		v.check(prefix+"route_indicator", routeIndicatorProblem(kind.RouteIndicator))
+	case kind.Type == "":
+		v.check(prefix+"route_indicator", "requires a type")
+	case kind.Type != domain.SignalMain && kind.Type != domain.SignalShunt:
+		v.check(prefix+"route_indicator", fmt.Sprintf("must not be set for a %s signal", kind.Type))
+	case kind.Type == domain.SignalShunt && kind.RouteIndicator != domain.RouteIndicatorTheatre:
+		v.check(prefix+"route_indicator", "must be theatre for a shunt signal")
+	}
+
+	if kind.Automatic && kind.Type != domain.SignalMain && kind.Type != domain.SignalDistant {
+		v.check(prefix+"automatic", "is only allowed for main and distant signals")
+	}
+}
+
+// signal checks a signal on its own, its ELR is optional until it is placed on a track.
+func (v *validation) signal(signal domain.Signal) {
+	v.check("id", idProblem(signal.ID))
+	v.check("signal_name", nameProblem(signal.Name, false))
+	if signal.ELR != "" {
+		v.check("elr", elrProblem(signal.ELR))
+	}
+	v.signalKind("", signal.SignalKind)
+}
+
+// signalFilter checks the attributes signals are filtered on.
+func (v *validation) signalFilter(filter domain.SignalFilter) {
+	v.check("type", signalTypeProblem(filter.Type))
+	if filter.Aspects != 0 && (filter.Aspects < 2 || filter.Aspects > 4) {
+		v.check("aspects", "must be 2, 3 or 4")
+	}
+	v.check("route_indicator", routeIndicatorProblem(filter.RouteIndicator))
+}
+
+// track checks a track on its own.
+func (v *validation) track(track domain.Track) {
+	v.check("id", idProblem(track.ID))
+	v.check("source", nameProblem(track.Source, true))
+	v.check("target", nameProblem(track.Target, true))
+	v.check("target", locationsProblem(track.Source, track.Target))
+}
+
+// mileage checks a signal's mileage on a track.
+func (v *validation) mileage(mileage domain.Mileage) {
+	v.check("signal_id", idProblem(mileage.SignalID))
+	v.check("track_id", idProblem(mileage.TrackID))
+	v.check("mileage", mileageProblem(mileage.Mileage))
+}
+
+// elr checks an ELR, its mileages must run from start to end.
+func (v *validation) elr(elr domain.ELR) {
+	v.check("code", elrProblem(elr.Code))
+	v.check("description", nameProblem(elr.Description, false))
+	v.check("start_mileage", mileageProblem(elr.StartMileage))
+	v.check("end_mileage", mileageProblem(elr.EndMileage))
+	if elr.EndMileage < elr.StartMileage {
+		v.check("end_mileage", "must not be less than start_mileage")
+	}
+	v.check("start_location", nameProblem(elr.StartLocation, false))
+	v.check("end_location", nameProblem(elr.EndLocation, false))
+}
+
+// route checks an interlocking route on its own, the tracks are checked against the network when it is created and set.
+func (v *validation) route(route domain.Route) {
+	v.check("id", idProblem(route.ID))
+	v.check("entry_signal_id", idProblem(route.EntrySignalID))
+	v.check("exit_signal_id", idProblem(route.ExitSignalID))
+	if route.EntrySignalID != 0 && route.ExitSignalID == route.EntrySignalID {
+		v.check("exit_signal_id", "must not be the entry signal")
+	}
+	if len(route.TrackIDs) == 0 {
+		v.check("track_ids", "is required")
+	}
+
+	onRoute := make(map[int]bool, len(route.TrackIDs))
+	for i, trackID := range route.TrackIDs {
+		field := fmt.Sprintf("track_ids[%d]", i)
+		v.check(field, idProblem(trackID))
+		if trackID != 0 && onRoute[trackID] {
+			v.check(field, "appears more than once on the route")
+		}
+		onRoute[trackID] = true
+	}
+}
+
+// point checks a point on its own, its tracks are checked against the network when it is written.
+func (v *validation) point(point domain.Point) {
+	v.check("id", idProblem(point.ID))
+	v.check("location", nameProblem(point.Location, true))
+	v.check("normal_track_id", idProblem(point.NormalTrackID))
+	v.check("reverse_track_id", idProblem(point.ReverseTrackID))
+	if point.NormalTrackID != 0 && point.ReverseTrackID == point.NormalTrackID {
+		v.check("reverse_track_id", "must not be the normal track")
+	}
+	if point.Position != "" {
+		if _, err := domain.ParsePointPosition(string(point.Position)); err != nil {
+			v.check("position", err.Error())
+		}
+	}
+}
+
+// trackSignals checks a track with its nested signals, the fields of a signal are prefixed with its position.
+func (v *validation) trackSignals(ts domain.TrackSignals) {
+	v.check("track_id", idProblem(ts.ID))
+	v.check("source", nameProblem(ts.Source, true))
+	v.check("target", nameProblem(ts.Target, true))
+	v.check("target", locationsProblem(ts.Source, ts.Target))
+
+	onTrack := make(map[int]bool, len(ts.Signals))
+	for i, signal := range ts.Signals {
+		prefix := fmt.Sprintf("signal_ids[%d].", i)
+		v.check(prefix+"signal_id", idProblem(signal.ID))
+		if signal.ID != 0 && onTrack[signal.ID] {
+			v.check(prefix+"signal_id", "appears more than once on the track")
+		}
+		onTrack[signal.ID] = true
+
+		v.check(prefix+"signal_name", nameProblem(signal.Name, false))
+		v.check(prefix+"elr", elrProblem(signal.ELR))
+		v.signalKind(prefix, signal.SignalKind)
+		if signal.Mileage == nil {
+			v.check(prefix+"mileage", "is required")
+		} else {
+			v.check(prefix+"mileage", mileageProblem(*signal.Mileage))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestSignalValidation(t *testing.T) {
+	tests := map[string]struct {
+		pathID int
+		signal domain.Signal
+
+		wantFields []domain.FieldError
+	}{
+		"valid signal": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, Name: "SIG1", ELR: "ECM1"},
+		},
+		"signal without an elr": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, Name: "SIG1"},
+		},
+		"id taken from the path": {
+			pathID: 1,
+			signal: domain.Signal{Name: "SIG1", ELR: "ABC"},
+		},
+		"automatic four aspect main signal": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{
+				Type: domain.SignalMain, Aspects: 4, RouteIndicator: domain.RouteIndicatorJunction, Automatic: true,
+			}},
+		},
+		"shunt signal with a theatre indicator": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{
+				Type: domain.SignalShunt, RouteIndicator: domain.RouteIndicatorTheatre,
+			}},
+		},
+		"unknown type": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{Type: "semaphore", Aspects: 2}},
+			wantFields: []domain.FieldError{
+				{Field: "type", Reason: "must be main, distant, shunt, banner_repeater or stop_board"},
+			},
+		},
+		"attributes without a type": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{Aspects: 3, RouteIndicator: domain.RouteIndicatorTheatre}},
+			wantFields: []domain.FieldError{
+				{Field: "aspects", Reason: "requires a type"},
+				{Field: "route_indicator", Reason: "requires a type"},
+			},
+		},
+		"combinations that don't exist": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{
+				Type: domain.SignalStopBoard, Aspects: 2, RouteIndicator: domain.RouteIndicatorJunction, Automatic: true,
+			}},
+			wantFields: []domain.FieldError{
+				{Field: "aspects", Reason: "must not be set for a stop_board signal"},
+				{Field: "route_indicator", Reason: "must not be set for a stop_board signal"},
+				{Field: "automatic", Reason: "is only allowed for main and distant signals"},
+			},
+		},
+		"distant signal with three aspects": {
+			pathID: 1,
+			signal: domain.Signal{ID: 1, ELR: "ABC", SignalKind: domain.SignalKind{Type: domain.SignalDistant, Aspects: 3}},
+			wantFields: []domain.FieldError{
+				{Field: "aspects", Reason: "must be 2 for a distant signal"},
+			},
+		},
+		"every problem is reported": {
+			pathID: 1,
+			signal: domain.Signal{ID: 2, Name: string(make([]byte, 256)), ELR: "ab1"},
+			wantFields: []domain.FieldError{
+				{Field: "id", Reason: "is 2 but the path is for 1"},
+				{Field: "signal_name", Reason: "is longer than 255 characters"},
+				{Field: "elr", Reason: "must be three capital letters, optionally followed by a letter or digit"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService()
+			_, err := s.CreateSignal(ctx, &domain.Signal{ID: 1, Name: "OLD", ELR: "ABC"}, domain.ConflictFail)
+			require.NoError(t, err, "creating signal")
+
+			err = s.UpdateSignal(ctx, test.pathID, &test.signal)
+			if test.wantFields == nil {
+				require.NoError(t, err, "updating signal")
+				return
+			}
+
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr, "updating signal")
+			assert.Equal(t, test.wantFields, validationErr.Fields, "fields")
+		})
+	}
+}
+
+func TestTrackSignalsValidation(t *testing.T) {
+	tests := map[string]struct {
+		track domain.TrackSignals
+
+		wantFields []domain.FieldError
+	}{
+		"valid track": {
+			track: domain.TrackSignals{ID: 1, Source: "A", Target: "B", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "SIG1", ELR: "ABC", Mileage: miles(0)},
+			}},
+		},
+		"loop and bad signals": {
+			track: domain.TrackSignals{ID: 1, Source: "A", Target: "A", Signals: []domain.TrackSignal{
+				{ID: 1, Name: "SIG1", ELR: "NULL", Mileage: miles(-1)},
+				{ID: 1, Name: "SIG1", ELR: ""},
+			}},
+			wantFields: []domain.FieldError{
+				{Field: "target", Reason: "must differ from source"},
+				{Field: "signal_ids[0].mileage", Reason: "must not be negative"},
+				{Field: "signal_ids[1].signal_id", Reason: "appears more than once on the track"},
+				{Field: "signal_ids[1].elr", Reason: "is required"},
+				{Field: "signal_ids[1].mileage", Reason: "is required"},
+				{Field: "signal_ids[0].elr", Reason: "is not registered"},
+			},
+		},
+		"blank locations": {
+			track: domain.TrackSignals{ID: -1, Source: " ", Target: ""},
+			wantFields: []domain.FieldError{
+				{Field: "track_id", Reason: "must be positive"},
+				{Field: "source", Reason: "is required"},
+				{Field: "target", Reason: "is required"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newTestService()
+
+			_, err := s.CreateTrackSignals(context.Background(), &test.track, domain.ConflictPolicies{})
+			if test.wantFields == nil {
+				require.NoError(t, err, "creating track")
+				return
+			}
+
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr, "creating track")
+			assert.Equal(t, test.wantFields, validationErr.Fields, "fields")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "fmt"
+
+// ConflictPolicy decides what creating an entity does when one with the same key already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the stored entity.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictUpsert overwrites the stored entity.
+	ConflictUpsert ConflictPolicy = "upsert"
+	// ConflictFail rejects the write with ErrConflict.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ParseConflictPolicy returns the policy with the given name.
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(name); policy {
+	case ConflictSkip, ConflictUpsert, ConflictFail:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("unknown conflict policy %q, must be skip, upsert or fail", name)
+}
+
+// Resolve decides the outcome of creating an entity that already exists.
+// Writing an entity identical to the stored one is always skipped, otherwise the policy decides.
+// An empty policy behaves like ConflictSkip.
+func (p ConflictPolicy) Resolve(identical bool) (WriteOutcome, error) {
+	switch {
+	case identical:
+		return WriteSkipped, nil
+	case p == ConflictUpsert:
+		return WriteUpdated, nil
+	case p == ConflictFail:
+		return "", ErrConflict
+	default:
+		return WriteSkipped, nil
+	}
+}
+
+// ConflictPolicies holds the policy for each entity type written by a track with nested signals.
+type ConflictPolicies struct {
+	Tracks   ConflictPolicy `json:"tracks"`
+	Signals  ConflictPolicy `json:"signals"`
+	Mileages ConflictPolicy `json:"mileages"`
+}
+
+// WriteOutcome is what creating an entity did.
+type WriteOutcome string
+
+const (
+	WriteInserted WriteOutcome = "inserted"
+	WriteUpdated  WriteOutcome = "updated"
+	WriteSkipped  WriteOutcome = "skipped"
+)
+
+// WriteCounts counts the outcomes of creating entities of one type.
+// Rejected entities were not written because they were invalid or conflicted with stored ones.
+type WriteCounts struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+	Rejected int `json:"rejected"`
+}
+
+// Add counts an outcome.
+func (c *WriteCounts) Add(outcome WriteOutcome) {
+	switch outcome {
+	case WriteInserted:
+		c.Inserted++
+	case WriteUpdated:
+		c.Updated++
+	case WriteSkipped:
+		c.Skipped++
+	}
+}
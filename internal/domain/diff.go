@@ -0,0 +1,43 @@
+package domain
+
+// DatasetDiff is what changes when the stored tracks, signals and mileages are made to match a dataset.
+type DatasetDiff struct {
+	Tracks   ChangeSet `json:"tracks"`
+	Signals  ChangeSet `json:"signals"`
+	Mileages ChangeSet `json:"mileages"`
+	// Applied is set when the changes have been written to the store.
+	Applied bool `json:"applied"`
+}
+
+// Empty reports whether the dataset matches what is stored.
+func (d *DatasetDiff) Empty() bool {
+	return d.Tracks.Empty() && d.Signals.Empty() && d.Mileages.Empty()
+}
+
+// ChangeSet holds the changes to one entity type, ordered by key.
+type ChangeSet struct {
+	Added    []Change `json:"added"`
+	Modified []Change `json:"modified"`
+	Removed  []Change `json:"removed"`
+}
+
+// Empty reports whether there are no changes.
+func (c *ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// Change is an added, modified or removed entity, identified by its key.
+// Added entities list every field with no before value, removed entities every field with no after value,
+// and modified entities only the fields that differ.
+type Change struct {
+	TrackID  int           `json:"track_id,omitempty"`
+	SignalID int           `json:"signal_id,omitempty"`
+	Fields   []FieldChange `json:"fields"`
+}
+
+// FieldChange is the value of a field before and after a change.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
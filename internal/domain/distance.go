@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Distance is a distance along the line, held exactly as a whole number of yards.
+// A mileage is the distance of a point from the zero of its ELR.
+//
+// As JSON a distance is written as decimal miles. It is read from a number of decimal miles or from
+// text in miles and chains or miles and yards, such as "12m 34ch" or "12m 748y".
+type Distance int64
+
+const (
+	Yard  Distance = 1
+	Chain Distance = 22 * Yard
+	Mile  Distance = 80 * Chain
+)
+
+// maxDistance keeps distances small enough to convert to and from float64 exactly.
+const maxDistance = 1 << 53
+
+// Miles returns the distance nearest to the decimal miles.
+func Miles(miles float64) Distance {
+	return Distance(math.Round(miles * float64(Mile)))
+}
+
+// Unit is a unit that distances are read and written in.
+type Unit string
+
+const (
+	// UnitMiles is decimal miles, the default.
+	UnitMiles      Unit = "miles"
+	UnitChains     Unit = "chains"
+	UnitYards      Unit = "yards"
+	UnitKilometres Unit = "km"
+	UnitMetres     Unit = "metres"
+	// UnitMilesChains writes distances as text such as "12m 34ch", numbers are read as decimal miles.
+	UnitMilesChains Unit = "miles-chains"
+	// UnitMilesYards writes distances as text such as "12m 748y", numbers are read as decimal miles.
+	UnitMilesYards Unit = "miles-yards"
+)
+
+// metresPerYard is the international yard.
+const metresPerYard = 0.9144
+
+// ParseUnit returns the unit with the given name.
+func ParseUnit(name string) (Unit, error) {
+	switch unit := Unit(name); unit {
+	case UnitMiles, UnitChains, UnitYards, UnitKilometres, UnitMetres, UnitMilesChains, UnitMilesYards:
+		return unit, nil
+	}
+
+	return "", fmt.Errorf("unknown unit %q, must be miles, chains, yards, km, metres, miles-chains or miles-yards", name)
+}
+
+// yards is the number of yards in one of the unit, the text units count in miles.
+// An empty unit is decimal miles.
+func (u Unit) yards() float64 {
+	switch u {
+	case UnitChains:
+		return float64(Chain)
+	case UnitYards:
+		return float64(Yard)
+	case UnitKilometres:
+		return 1000 / metresPerYard
+	case UnitMetres:
+		return 1 / metresPerYard
+	default:
+		return float64(Mile)
+	}
+}
+
+// text reports whether distances are written in the unit as text rather than as numbers.
+func (u Unit) text() bool {
+	return u == UnitMilesChains || u == UnitMilesYards
+}
+
+// DistanceIn returns the distance nearest to the value in the unit.
+func DistanceIn(value float64, unit Unit) (Distance, error) {
+	yards := math.Round(value * unit.yards())
+	if math.IsNaN(yards) || math.Abs(yards) > maxDistance {
+		return 0, fmt.Errorf("distance %v %s is out of range", value, unit)
+	}
+
+	return Distance(yards), nil
+}
+
+// In returns the distance as a number of the unit.
+// Metric distances are multiplied out from the exact length of a yard to avoid rounding noise.
+func (d Distance) In(unit Unit) float64 {
+	switch unit {
+	case UnitKilometres:
+		return float64(d) * metresPerYard / 1000
+	case UnitMetres:
+		return float64(d) * metresPerYard
+	}
+	return float64(d) / unit.yards()
+}
+
+// distanceTerm matches one term of a distance written as text, such as "12m" or "34 ch".
+var distanceTerm = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([a-z]+)\s*`)
+
+// termUnits are the abbreviations accepted for the terms of a distance written as text.
+// m is miles, as usual on the railway, metres must be spelt out.
+var termUnits = map[string]Unit{
+	"m": UnitMiles, "mi": UnitMiles, "mile": UnitMiles, "miles": UnitMiles,
+	"ch": UnitChains, "chain": UnitChains, "chains": UnitChains,
+	"y": UnitYards, "yd": UnitYards, "yds": UnitYards, "yard": UnitYards, "yards": UnitYards,
+	"km": UnitKilometres, "metre": UnitMetres, "metres": UnitMetres, "meter": UnitMetres, "meters": UnitMetres,
+}
+
+// ParseDistance reads a distance from text. A plain number is read in the unit, otherwise the text is a
+// sum of terms each with their own unit, such as "12m 34ch", "12m 34ch 5y", "12m 748y" or "1.5km".
+func ParseDistance(text string, unit Unit) (Distance, error) {
+	if value, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+		return DistanceIn(value, unit)
+	}
+
+	rest := strings.ToLower(strings.TrimSpace(text))
+	negative := strings.HasPrefix(rest, "-")
+	rest = strings.TrimPrefix(rest, "-")
+	if rest == "" {
+		return 0, fmt.Errorf("invalid distance %q", text)
+	}
+
+	var total Distance
+	for rest != "" {
+		match := distanceTerm.FindStringSubmatch(rest)
+		if match == nil {
+			return 0, fmt.Errorf("invalid distance %q, expected miles and chains such as 12m 34ch", text)
+		}
+		termUnit, ok := termUnits[match[2]]
+		if !ok {
+			return 0, fmt.Errorf("invalid distance %q, unknown unit %q", text, match[2])
+		}
+
+		value, _ := strconv.ParseFloat(match[1], 64)
+		term, err := DistanceIn(value, termUnit)
+		if err != nil {
+			return 0, err
+		}
+		total += term
+		rest = rest[len(match[0]):]
+	}
+
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// Format writes the distance in the unit, as a decimal number or as text for the text units.
+func (d Distance) Format(unit Unit) string {
+	if !unit.text() {
+		return strconv.FormatFloat(d.In(unit), 'f', -1, 64)
+	}
+
+	sign, yards := "", d
+	if yards < 0 {
+		sign, yards = "-", -yards
+	}
+	miles, yards := yards/Mile, yards%Mile
+
+	if unit == UnitMilesYards {
+		return fmt.Sprintf("%s%dm %dy", sign, miles, yards)
+	}
+	if yards%Chain == 0 {
+		return fmt.Sprintf("%s%dm %dch", sign, miles, yards/Chain)
+	}
+	return fmt.Sprintf("%s%dm %dch %dy", sign, miles, yards/Chain, yards%Chain)
+}
+
+// String writes the distance in miles and chains.
+func (d Distance) String() string {
+	return d.Format(UnitMilesChains)
+}
+
+// MarshalJSON writes the distance as decimal miles.
+func (d Distance) MarshalJSON() ([]byte, error) {
+	return d.MarshalJSONIn(UnitMiles)
+}
+
+// MarshalJSONIn writes the distance in the unit, as a string for the text units.
+func (d Distance) MarshalJSONIn(unit Unit) ([]byte, error) {
+	if unit.text() {
+		return json.Marshal(d.Format(unit))
+	}
+	return []byte(d.Format(unit)), nil
+}
+
+// UnmarshalJSON reads the distance from decimal miles or from text.
+func (d *Distance) UnmarshalJSON(data []byte) error {
+	return d.UnmarshalJSONIn(data, UnitMiles)
+}
+
+// UnmarshalJSONIn reads the distance from a number in the unit or from text.
+func (d *Distance) UnmarshalJSONIn(data []byte, unit Unit) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	distance, err := ParseDistance(text, unit)
+	if err != nil {
+		return err
+	}
+
+	*d = distance
+	return nil
+}
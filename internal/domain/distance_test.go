@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/warrenb95/railway-signals/internal/domain"
+)
+
+func TestParseDistance(t *testing.T) {
+	tests := map[string]struct {
+		text string
+		unit domain.Unit
+
+		want          domain.Distance
+		wantFormatted string
+		errorContains string
+	}{
+		"miles and chains": {
+			text:          "12m 34ch",
+			want:          12*domain.Mile + 34*domain.Chain,
+			wantFormatted: "12m 34ch",
+		},
+		"miles, chains and yards": {
+			text:          "12m34ch 5y",
+			want:          12*domain.Mile + 34*domain.Chain + 5,
+			wantFormatted: "12m 34ch 5y",
+		},
+		"miles and yards": {
+			text:          "12 miles 748 yards",
+			want:          12*domain.Mile + 748,
+			wantFormatted: "12m 34ch",
+		},
+		"kilometres": {
+			text:          "1.609344km",
+			want:          domain.Mile,
+			wantFormatted: "1m 0ch",
+		},
+		"plain number in the unit": {
+			text:          "12.5",
+			unit:          domain.UnitChains,
+			want:          275,
+			wantFormatted: "0m 12ch 11y",
+		},
+		"negative": {
+			text:          "-1m 1ch",
+			want:          -domain.Mile - domain.Chain,
+			wantFormatted: "-1m 1ch",
+		},
+		"metres are spelt out": {
+			text:          "12 m 100 metres",
+			want:          12*domain.Mile + 109,
+			wantFormatted: "12m 4ch 21y",
+		},
+		"unknown unit": {
+			text:          "3 furlongs",
+			errorContains: `unknown unit "furlongs"`,
+		},
+		"not a distance": {
+			text:          "12m and a bit",
+			errorContains: "expected miles and chains",
+		},
+		"out of range": {
+			text:          "1e300",
+			errorContains: "out of range",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := domain.ParseDistance(test.text, test.unit)
+			if test.errorContains != "" {
+				require.ErrorContains(t, err, test.errorContains, "parse error contains")
+				return
+			}
+
+			require.NoError(t, err, "parsing distance")
+			assert.Equal(t, test.want, got, "distance")
+			assert.Equal(t, test.wantFormatted, got.String(), "formatted distance")
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when the requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write clashes with data already in a store.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation is returned when an entity breaks one of the rules on its fields.
+	ErrValidation = errors.New("validation failed")
+)
+
+// Entity names the kind of entity an error is about.
+type Entity string
+
+const (
+	EntitySignal  Entity = "signal"
+	EntityTrack   Entity = "track"
+	EntityMileage Entity = "mileage"
+	EntityLoadJob Entity = "load job"
+	EntityELR     Entity = "elr"
+	EntityRoute   Entity = "route"
+	EntityPoint   Entity = "point"
+)
+
+// NotFoundError is returned when an entity does not exist, it matches ErrNotFound.
+type NotFoundError struct {
+	Entity Entity
+	// Key identifies the entity, for example "12" or "of signal 1 on track 2".
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Entity, e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ConflictError is returned when a write clashes with a stored entity, it matches ErrConflict.
+type ConflictError struct {
+	Entity Entity
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Reason
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// ValidationError lists every field of an entity that breaks a rule, it matches ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// FieldError is a rule broken by a field.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		problems = append(problems, f.Field+" "+f.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(problems, ", "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Invalid returns a ValidationError for a single field.
+func Invalid(field, reason string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Reason: reason}}}
+}
@@ -1,15 +1,86 @@
 package domain
 
+import (
+	"fmt"
+	"time"
+)
+
 type Signal struct {
 	ID   int    `json:"id"`
 	Name string `json:"signal_name"`
 	ELR  string `json:"elr"`
+	SignalKind
+}
+
+// SignalKind describes what a signal is, the aspects it shows and how it is worked.
+// A signal without a type is unclassified and has none of the other attributes.
+type SignalKind struct {
+	Type SignalType `json:"type,omitempty"`
+	// Aspects is the number of aspects of a colour-light main or distant signal.
+	Aspects        int            `json:"aspects,omitempty"`
+	RouteIndicator RouteIndicator `json:"route_indicator,omitempty"`
+	// Automatic signals are worked by the trains passing them, controlled signals by a signaller.
+	Automatic bool `json:"automatic,omitempty" pg:",use_zero"`
+}
+
+// SignalType is the kind of signal.
+type SignalType string
+
+const (
+	// SignalMain is a colour-light stop signal.
+	SignalMain SignalType = "main"
+	// SignalDistant is a colour-light signal giving advance warning of the main signal ahead, it shows caution or clear.
+	SignalDistant SignalType = "distant"
+	// SignalShunt is a position-light signal for shunting movements.
+	SignalShunt SignalType = "shunt"
+	// SignalBannerRepeater repeats the aspect of a main signal that is hard to see.
+	SignalBannerRepeater SignalType = "banner_repeater"
+	// SignalStopBoard is a fixed board where trains stop for permission to go on.
+	SignalStopBoard SignalType = "stop_board"
+)
+
+// ParseSignalType returns the signal type with the given name.
+func ParseSignalType(name string) (SignalType, error) {
+	switch t := SignalType(name); t {
+	case SignalMain, SignalDistant, SignalShunt, SignalBannerRepeater, SignalStopBoard:
+		return t, nil
+	}
+
+	return "", fmt.Errorf("unknown signal type %q, must be main, distant, shunt, banner_repeater or stop_board", name)
+}
+
+// RouteIndicator is the indicator a signal shows the route set from it with.
+type RouteIndicator string
+
+const (
+	// RouteIndicatorJunction is a junction indicator, a row of white lights pointing along a diverging route.
+	RouteIndicatorJunction RouteIndicator = "junction"
+	// RouteIndicatorTheatre is a theatre indicator, showing a character for the route.
+	RouteIndicatorTheatre RouteIndicator = "theatre"
+)
+
+// ParseRouteIndicator returns the route indicator with the given name.
+func ParseRouteIndicator(name string) (RouteIndicator, error) {
+	switch i := RouteIndicator(name); i {
+	case RouteIndicatorJunction, RouteIndicatorTheatre:
+		return i, nil
+	}
+
+	return "", fmt.Errorf("unknown route indicator %q, must be junction or theatre", name)
+}
+
+// SignalFilter narrows a list of signals to those with every attribute that is set.
+type SignalFilter struct {
+	Type           SignalType
+	Aspects        int
+	RouteIndicator RouteIndicator
+	Automatic      *bool
 }
 
 type Mileage struct {
-	SignalID int     `json:"signal_id"`
-	TrackID  int     `json:"track_id"`
-	Mileage  float64 `json:"mileage"`
+	SignalID int      `json:"signal_id"`
+	TrackID  int      `json:"track_id"`
+	Mileage  Distance `json:"mileage" pg:",use_zero"`
 }
 
 type Track struct {
@@ -19,15 +90,325 @@ type Track struct {
 }
 
 type TrackSignals struct {
-	ID      int    `json:"track_id"`
-	Source  string `json:"source"`
-	Target  string `json:"target"`
-	Signals []struct {
-		ID      int     `json:"signal_id"`
-		Name    string  `json:"signal_name"`
-		ELR     string  `json:"elr"`
-		Mileage float64 `json:"mileage"`
-	} `json:"signal_ids"`
+	ID      int           `json:"track_id"`
+	Source  string        `json:"source"`
+	Target  string        `json:"target"`
+	Signals []TrackSignal `json:"signal_ids"`
+}
+
+// TrackSignal is a signal along with its mileage on a track.
+// Mileage is only nil in loaded input where the mileage is missing.
+type TrackSignal struct {
+	ID      int       `json:"signal_id"`
+	Name    string    `json:"signal_name"`
+	ELR     string    `json:"elr"`
+	Mileage *Distance `json:"mileage"`
+	SignalKind
 }
 
 type TrackSignalSlice []TrackSignals
+
+// ELR is an Engineer's Line Reference, the code of a line of route that signal mileages are measured along.
+type ELR struct {
+	Code          string   `json:"code"`
+	Description   string   `json:"description"`
+	StartMileage  Distance `json:"start_mileage"`
+	EndMileage    Distance `json:"end_mileage"`
+	StartLocation string   `json:"start_location"`
+	EndLocation   string   `json:"end_location"`
+}
+
+// ELRSignal is a signal on an ELR with its mileage on one of the tracks it is on.
+type ELRSignal struct {
+	TrackID int `json:"track_id"`
+	TrackSignal
+}
+
+// NearbySignal is a signal on an ELR with its distance from a mileage on the same ELR.
+// Direction is the way from the mileage to the signal, it is empty for a signal at the mileage.
+type NearbySignal struct {
+	ELRSignal
+	Distance  Distance  `json:"distance"`
+	Direction Direction `json:"direction,omitempty"`
+}
+
+// BlockSection is the stretch of a track between two consecutive signals, the basic unit of capacity and occupancy.
+// A train enters the section at the entry signal and leaves it at the exit signal, the mileages are theirs.
+// Length is nil when the two signals are measured along different ELRs.
+type BlockSection struct {
+	TrackID      int         `json:"track_id"`
+	Direction    Direction   `json:"direction"`
+	EntrySignal  TrackSignal `json:"entry_signal"`
+	ExitSignal   TrackSignal `json:"exit_signal"`
+	StartMileage Distance    `json:"start_mileage"`
+	EndMileage   Distance    `json:"end_mileage"`
+	Length       *Distance   `json:"length"`
+}
+
+// Point is a set of points at a location where a line divides in two, the normal track or the reverse track.
+// Trains passing through the location can only run onto or off the track the point is set to.
+type Point struct {
+	ID             int           `json:"id"`
+	Location       string        `json:"location"`
+	NormalTrackID  int           `json:"normal_track_id"`
+	ReverseTrackID int           `json:"reverse_track_id"`
+	Position       PointPosition `json:"position"`
+}
+
+// PointPosition is the way a point is set.
+type PointPosition string
+
+const (
+	PointNormal  PointPosition = "normal"
+	PointReverse PointPosition = "reverse"
+)
+
+// ParsePointPosition returns the point position with the given name.
+func ParsePointPosition(name string) (PointPosition, error) {
+	switch position := PointPosition(name); position {
+	case PointNormal, PointReverse:
+		return position, nil
+	}
+
+	return "", fmt.Errorf("unknown point position %q, must be normal or reverse", name)
+}
+
+// ClosedTrackID is the branch the point is not set to.
+func (p Point) ClosedTrackID() int {
+	if p.Position == PointReverse {
+		return p.NormalTrackID
+	}
+	return p.ReverseTrackID
+}
+
+// Route is an interlocking route, the way a train is signalled from an entry signal to an exit signal
+// over consecutive tracks. While a route is set no route that conflicts with it can be set.
+type Route struct {
+	ID            int        `json:"id"`
+	EntrySignalID int        `json:"entry_signal_id"`
+	ExitSignalID  int        `json:"exit_signal_id"`
+	TrackIDs      []int      `json:"track_ids" pg:",array"`
+	State         RouteState `json:"state"`
+}
+
+// RouteState is whether an interlocking route is set for a train.
+type RouteState string
+
+const (
+	RouteReleased RouteState = "released"
+	RouteSet      RouteState = "set"
+)
+
+// ParseRouteState returns the route state with the given name.
+func ParseRouteState(name string) (RouteState, error) {
+	switch state := RouteState(name); state {
+	case RouteReleased, RouteSet:
+		return state, nil
+	}
+
+	return "", fmt.Errorf("unknown route state %q, must be released or set", name)
+}
+
+// Aspect is the indication shown by a colour-light signal, from most to least restrictive.
+type Aspect string
+
+const (
+	AspectRed          Aspect = "red"
+	AspectYellow       Aspect = "yellow"
+	AspectDoubleYellow Aspect = "double_yellow"
+	AspectGreen        Aspect = "green"
+)
+
+// Occupancy is the block sections of a track occupied by trains, each given by the ID of its entry signal.
+type Occupancy struct {
+	Occupied []int `json:"occupied"`
+}
+
+// SignalAspect is a signal on a track with the aspect it shows.
+// Aspect is empty for signals that aren't colour-light main or distant signals.
+type SignalAspect struct {
+	TrackSignal
+	Aspect Aspect `json:"aspect,omitempty"`
+}
+
+// AspectSimulation is the aspect of every signal on a track in the order of travel, for the occupied block sections.
+type AspectSimulation struct {
+	TrackID   int            `json:"track_id"`
+	Direction Direction      `json:"direction"`
+	Occupied  []int          `json:"occupied"`
+	Signals   []SignalAspect `json:"signals"`
+}
+
+// Train is a train to run through a movement simulation, along the shortest path between two locations.
+// Speeds are in miles per hour and rates in metres per second squared, as they are quoted on the railway.
+type Train struct {
+	ID           string   `json:"id"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Length       Distance `json:"length"`
+	MaxSpeed     float64  `json:"max_speed"`
+	Acceleration float64  `json:"acceleration"`
+	Braking      float64  `json:"braking"`
+	// Depart is the number of seconds after the start of the simulation that the train sets off.
+	Depart float64 `json:"depart"`
+}
+
+// MovementPlan is the trains to run through a movement simulation.
+// Time advances in ticks of Tick seconds until every train has arrived or Duration seconds have passed.
+type MovementPlan struct {
+	Tick     float64 `json:"tick"`
+	Duration float64 `json:"duration"`
+	Trains   []Train `json:"trains"`
+}
+
+// TrainState is where a train is in a movement simulation.
+type TrainState string
+
+const (
+	TrainWaiting TrainState = "waiting"
+	TrainRunning TrainState = "running"
+	TrainStopped TrainState = "stopped"
+	TrainArrived TrainState = "arrived"
+)
+
+// TrainEventType is something that happens during a movement simulation.
+type TrainEventType string
+
+const (
+	EventDeparted      TrainEventType = "departed"
+	EventSignalPassed  TrainEventType = "signal_passed"
+	EventStoppedAtRed  TrainEventType = "stopped_at_red"
+	EventAspectChanged TrainEventType = "aspect_changed"
+	EventArrived       TrainEventType = "arrived"
+)
+
+// TrainEvent is an entry in the timeline of a movement simulation, Time is in seconds from the start.
+// Aspect changes are of a signal seen by trains travelling in the direction along the track, they have no train.
+type TrainEvent struct {
+	Time           float64        `json:"time"`
+	Type           TrainEventType `json:"type"`
+	Train          string         `json:"train,omitempty"`
+	TrackID        int            `json:"track_id,omitempty"`
+	SignalID       int            `json:"signal_id,omitempty"`
+	Direction      Direction      `json:"direction,omitempty"`
+	Aspect         Aspect         `json:"aspect,omitempty"`
+	PreviousAspect Aspect         `json:"previous_aspect,omitempty"`
+	Speed          *float64       `json:"speed,omitempty"`
+}
+
+// TrainProgress is how far a train got by the end of a movement simulation, with its speed in miles per hour.
+type TrainProgress struct {
+	ID        string     `json:"id"`
+	State     TrainState `json:"state"`
+	Travelled Distance   `json:"travelled"`
+	Speed     float64    `json:"speed"`
+}
+
+// MovementLog is the outcome of a movement simulation, the events are in the order they happened.
+type MovementLog struct {
+	Duration float64         `json:"duration"`
+	Trains   []TrainProgress `json:"trains"`
+	Events   []TrainEvent    `json:"events"`
+}
+
+// Direction is a direction of travel along a track.
+// Mileages increase in the down direction, following the usual British convention.
+type Direction string
+
+const (
+	DirectionDown Direction = "down"
+	DirectionUp   Direction = "up"
+)
+
+// Reverse returns the opposite direction.
+func (d Direction) Reverse() Direction {
+	if d == DirectionUp {
+		return DirectionDown
+	}
+	return DirectionUp
+}
+
+// AdjacentSignal is the signal next to another along the line, with the distance between them.
+// Direction is the direction of travel on the signal's track, it differs from the starting track where tracks
+// meet end to end. Distance is nil when the two signals are measured along different ELRs.
+type AdjacentSignal struct {
+	TrackID int `json:"track_id"`
+	TrackSignal
+	Direction Direction `json:"direction"`
+	Distance  *Distance `json:"distance"`
+}
+
+// Path is a sequence of connected tracks between two locations.
+type Path struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Hops   int      `json:"hops"`
+	Length Distance `json:"length"`
+
+	Tracks  []PathTrack  `json:"tracks"`
+	Signals []PathSignal `json:"signals"`
+}
+
+// PathTrack is a track on a path, reversed tracks are travelled from Target to Source.
+type PathTrack struct {
+	Track
+	Reversed bool `json:"reversed"`
+}
+
+// PathSignal is a signal passed on a path.
+type PathSignal struct {
+	TrackID int `json:"track_id"`
+	TrackSignal
+}
+
+// LoadJobState is the lifecycle state of a load job.
+type LoadJobState string
+
+const (
+	LoadJobQueued    LoadJobState = "queued"
+	LoadJobRunning   LoadJobState = "running"
+	LoadJobSucceeded LoadJobState = "succeeded"
+	LoadJobFailed    LoadJobState = "failed"
+	LoadJobCancelled LoadJobState = "cancelled"
+)
+
+// LoadJob is an uploaded TrackSignalSlice being loaded in the background.
+type LoadJob struct {
+	ID         string           `json:"id"`
+	State      LoadJobState     `json:"state"`
+	Policies   ConflictPolicies `json:"policies"`
+	Unit       Unit             `json:"unit,omitempty"`
+	Progress   LoadProgress     `json:"progress"`
+	Errors     []string         `json:"errors,omitempty"`
+	Issues     []LoadIssue      `json:"issues,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+// LoadProgress counts the outcome of the records a load has processed so far.
+type LoadProgress struct {
+	Tracks   WriteCounts `json:"tracks"`
+	Signals  WriteCounts `json:"signals"`
+	Mileages WriteCounts `json:"mileages"`
+}
+
+// LoadIssue is a problem with a record of a bulk load.
+// Index is the position of the track in the loaded array, SignalID is zero for track level issues.
+type LoadIssue struct {
+	Index    int    `json:"index"`
+	TrackID  int    `json:"track_id"`
+	SignalID int    `json:"signal_id,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// LoadReport is the outcome of validating a bulk load without writing it.
+type LoadReport struct {
+	Valid   bool `json:"valid"`
+	Tracks  int  `json:"tracks"`
+	Signals int  `json:"signals"`
+	// Truncated is set when there were more issues than the report holds.
+	Truncated bool        `json:"truncated"`
+	Issues    []LoadIssue `json:"issues"`
+}
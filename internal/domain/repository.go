@@ -3,23 +3,87 @@ package domain
 import "context"
 
 type SignalStore interface {
-	CreateSignal(ctx context.Context, signal *Signal) error
+	CreateSignal(ctx context.Context, signal *Signal, policy ConflictPolicy) (WriteOutcome, error)
 	GetSignal(ctx context.Context, signalID int) (*Signal, error)
-	ListSignals(ctx context.Context, limit, page int) (signals []Signal, count int, err error)
+	ListSignals(ctx context.Context, filter SignalFilter, limit, page int) (signals []Signal, count int, err error)
+	// ListUnplacedSignals lists the signals that aren't on any track ordered by ID.
+	ListUnplacedSignals(ctx context.Context, limit, page int) (signals []Signal, count int, err error)
 	UpdateSignal(ctx context.Context, signal *Signal) error
 	DeleteSignal(ctx context.Context, signalID int) error
 }
 
 type TrackStore interface {
-	CreateTrack(ctx context.Context, track *Track) error
+	CreateTrack(ctx context.Context, track *Track, policy ConflictPolicy) (WriteOutcome, error)
 	GetTrack(ctx context.Context, trackID int) (*Track, error)
 	ListTracks(ctx context.Context, limit, page int) (tracks []Track, count int, err error)
 	UpdateTrack(ctx context.Context, track *Track) error
 	DeleteTrack(ctx context.Context, trackID int) error
 
 	ListSignalTracks(ctx context.Context, signalID, limit, page int) (tracks []Track, count int, err error)
+	GetTrackSignals(ctx context.Context, trackID int) (*TrackSignals, error)
+	ListTrackSignals(ctx context.Context, limit, page int) (tracks []TrackSignals, count int, err error)
 }
 
 type MileageStore interface {
-	AddMileage(ctx context.Context, mileage *Mileage) error
+	AddMileage(ctx context.Context, mileage *Mileage, policy ConflictPolicy) (WriteOutcome, error)
+	GetMileage(ctx context.Context, signalID, trackID int) (*Mileage, error)
+	DeleteMileage(ctx context.Context, signalID, trackID int) error
+
+	ListTrackMileages(ctx context.Context, trackID, limit, page int) (mileages []Mileage, count int, err error)
+	ListSignalMileages(ctx context.Context, signalID, limit, page int) (mileages []Mileage, count int, err error)
+}
+
+type ELRStore interface {
+	CreateELR(ctx context.Context, elr *ELR, policy ConflictPolicy) (WriteOutcome, error)
+	GetELR(ctx context.Context, code string) (*ELR, error)
+	ListELRs(ctx context.Context, limit, page int) (elrs []ELR, count int, err error)
+	UpdateELR(ctx context.Context, elr *ELR) error
+	DeleteELR(ctx context.Context, code string) error
+
+	// ListELRSignals lists the signals on the ELR that are on a track, ordered by mileage.
+	ListELRSignals(ctx context.Context, code string, limit, page int) (signals []ELRSignal, count int, err error)
+	// ListSignalsNear lists the signals on the ELR from the mileage onwards in the direction, nearest first.
+	// A zero limit lists every signal.
+	ListSignalsNear(ctx context.Context, code string, mileage Distance, direction Direction, limit int) ([]NearbySignal, error)
+}
+
+type RouteStore interface {
+	CreateRoute(ctx context.Context, route *Route) error
+	GetRoute(ctx context.Context, routeID int) (*Route, error)
+	// ListRoutes lists the routes in the state ordered by ID, every route when the state is empty.
+	ListRoutes(ctx context.Context, state RouteState, limit, page int) (routes []Route, count int, err error)
+	SetRouteState(ctx context.Context, routeID int, state RouteState) error
+	DeleteRoute(ctx context.Context, routeID int) error
+
+	// LockRoutes keeps other transactions from changing routes until the transaction it is called in ends.
+	LockRoutes(ctx context.Context) error
+}
+
+type PointStore interface {
+	CreatePoint(ctx context.Context, point *Point) error
+	GetPoint(ctx context.Context, pointID int) (*Point, error)
+	// ListPoints lists the points at the location ordered by ID, every point when the location is empty.
+	ListPoints(ctx context.Context, location string, limit, page int) (points []Point, count int, err error)
+	UpdatePoint(ctx context.Context, point *Point) error
+	DeletePoint(ctx context.Context, pointID int) error
+}
+
+// Tx is a transactional view of every store.
+type Tx interface {
+	SignalStore
+	TrackStore
+	MileageStore
+	ELRStore
+	RouteStore
+	PointStore
+}
+
+// Transactor opens units of work spanning every store.
+type Transactor interface {
+	// InTransaction calls fn with a transactional view of the stores.
+	// Everything written through the view commits together when fn returns nil and rolls back when it returns an error.
+	InTransaction(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
+	// InSnapshot calls fn with a read-only view of the stores that doesn't change until fn returns,
+	// so every read sees the stores as they were when the view was opened.
+	InSnapshot(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
 }